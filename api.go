@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// apiMainSource renders the API entrypoint of a service
+func apiMainSource(project, service string) string {
+	std := []string{"context", "fmt", "log", "net", "net/http"}
+	load, loadMods := loadConfigCall(project, service)
+	if opts.ConfigValidate {
+		std = append(std, "errors", "io/fs")
+		load += `
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}`
+	}
+	mods := append([]string{project + "/shared/config", serviceImport(project, service, "api")}, loadMods...)
+
+	// Values read from config, with the fallback used when it cannot be loaded
+	vars := []string{"port := 8081"}
+	assign := []string{"port = config.Server.Port"}
+
+	// With --api-version the sample routes go on a mux mounted under
+	// /api/<version>; operational routes such as /version stay unversioned
+	on, handlers := "mux", "api"
+	if versionedAPI() {
+		on, handlers = "versioned", handlersPkg()
+		mods = append(mods, serviceImport(project, service, handlersRel()))
+	}
+	routes := []string{
+		on + `.HandleFunc("/hello", ` + handlers + `.HelloHandler)`,
+		`mux.HandleFunc("/version", api.VersionHandler)`,
+		on + `.HandleFunc("POST /greet", ` + handlers + `.GreetHandler)`,
+		on + `.HandleFunc("GET /items", ` + handlers + `.ListItemsHandler)`,
+	}
+	if layeredArch() {
+		mods = append(mods, "httpdelivery "+serviceImport(project, service, "delivery/http"))
+		mods = append(mods, greeterImports(project, service)...)
+		routes = []string{
+			"handler := httpdelivery.NewHandler(" + newGreeter(service) + ")",
+			on + `.HandleFunc("/hello", handler.Hello)`,
+			`mux.HandleFunc("/version", api.VersionHandler)`,
+			on + `.HandleFunc("POST /greet", handler.Greet)`,
+			on + `.HandleFunc("GET /items", ` + handlers + `.ListItemsHandler)`,
+		}
+	}
+
+	// Middlewares chained around the router, outermost first, inside the
+	// api.Wrap stack: excess requests are rejected before a deadline starts
+	var chain []string
+
+	// Statements run before the API server starts listening
+	var before []string
+
+	setup := ""
+	if opts.EnvPrefix != "" {
+		mods = append(mods, "github.com/joho/godotenv")
+		setup += `	// Load a local .env in development; variables already set take precedence
+	godotenv.Load()
+
+`
+	}
+	if opts.StructuredLogging {
+		std = append(std, "log/slog", "os")
+		logHandler := "slog.NewJSONHandler(os.Stdout, nil)"
+		if opts.OTelLogs {
+			mods = append(mods, project+"/shared/logging")
+			logHandler = "logging.NewTraceHandler(" + logHandler + ")"
+		}
+		setup += `	slog.SetDefault(slog.New(` + logHandler + `))
+
+`
+	}
+
+	if opts.Health {
+		routes = append(routes, `mux.HandleFunc("GET /healthz", api.HealthHandler)`)
+	}
+
+	if opts.Auth == "jwt" {
+		vars = append(vars, `jwtSecret := ""`)
+		assign = append(assign, "jwtSecret = config.Server.JWTSecret")
+		routes = append(routes, on+`.Handle("/private", api.RequireJWT(jwtSecret)(http.HandlerFunc(api.PrivateHandler)))`)
+	}
+
+	if opts.RateLimit {
+		mods = append(mods, project+"/shared/middleware")
+		vars = append(vars, "rateLimit, rateBurst := 10.0, 20")
+		assign = append(assign, "rateLimit, rateBurst = config.RateLimit.RequestsPerSecond, config.RateLimit.Burst")
+		chain = append(chain, "middleware.RateLimit(rateLimit, rateBurst)")
+	}
+
+	if opts.TimeoutMiddleware {
+		std = append(std, "time")
+		mods = append(mods, project+"/shared/middleware")
+		vars = append(vars, "requestTimeout := 5 * time.Second")
+		assign = append(assign, "requestTimeout = config.Context.Timeout")
+		chain = append(chain, "middleware.Timeout(requestTimeout)")
+	}
+
+	if opts.Systemd && opts.EnvPrefix == "" {
+		std = append(std, "os", "strconv")
+		// The systemd unit sets PORT, which takes precedence over config.
+		// With --env-prefix the config loader handles it instead.
+		before = append(before, `if p, err := strconv.Atoi(os.Getenv("PORT")); err == nil {
+		port = p
+	}
+
+	`)
+	}
+
+	if opts.Cache == "redis" {
+		std = append(std, "context")
+		mods = append(mods, project+"/shared/cache")
+		vars = append(vars, `cacheAddr, cachePassword, cacheDB := "localhost:6379", "", 0`)
+		assign = append(assign, "cacheAddr, cachePassword, cacheDB = config.Cache.Addr, config.Cache.Password, config.Cache.DB")
+		routes = append(routes,
+			"redisCache := cache.New(cacheAddr, cachePassword, cacheDB)",
+			`mux.HandleFunc("/readyz", api.ReadyHandler(map[string]func(context.Context) error{"redis": redisCache.Ping}))`)
+	}
+
+	if opts.Messaging == "nats" {
+		mods = append(mods, project+"/shared/messaging", serviceImport(project, service, "internal/events"))
+		vars = append(vars, `messagingURL := "nats://localhost:4222"`)
+		assign = append(assign, "messagingURL = config.Messaging.URL")
+		// The API keeps serving when NATS is unreachable
+		before = append(before, fmt.Sprintf(`if bus, err := messaging.Connect(messagingURL, "%s-api"); err != nil {
+		log.Printf("⚠️ Messaging disabled: %%v\n", err)
+	} else if err := events.ConsumeGreeted(bus); err != nil {
+		log.Printf("⚠️ Failed to subscribe to %%s: %%v\n", events.GreetedSubject, err)
+	}
+
+	`, service))
+	}
+
+	if opts.ExampleCRUD {
+		mods = append(mods, serviceImport(project, service, "db"), serviceImport(project, service, "internal/repository"))
+		routes = append(routes,
+			"database, err := db.Open(config)",
+			`if err != nil {
+		return fmt.Errorf("failed to open the database: %w", err)
+	}`,
+			"api.NewExampleHandler(repository.NewExampleRepository(database)).Routes("+on+")")
+	}
+
+	if opts.GRPCGateway {
+		gatewayMods, gatewayRoutes := gatewayRoutes(project, service)
+		mods = append(mods, gatewayMods...)
+		vars = append(vars, "grpcPort := 9081")
+		assign = append(assign, "grpcPort = config.Server.GRPCPort")
+		routes = append(routes, gatewayRoutes...)
+	}
+
+	if opts.FeatureFlags {
+		mods = append(mods, project+"/shared/flags")
+		vars = append(vars, "features := flags.Static{}")
+		assign = append(assign, "features = flags.Static(config.Features)")
+		routes = append(routes, on+`.HandleFunc("GET /beta", api.BetaHandler(features))`)
+	}
+
+	if opts.Pprof {
+		std = append(std, "net/http/pprof")
+		// Off unless config enables it, so a missing config never exposes it
+		vars = append(vars, "pprofEnabled, pprofPort := false, 0")
+		assign = append(assign, "pprofEnabled, pprofPort = config.Pprof.Enabled, config.Pprof.Port")
+		before = append(before, `if pprofEnabled {
+		debug := http.NewServeMux()
+		debug.HandleFunc("/debug/pprof/", pprof.Index)
+		debug.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		debug.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		debug.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		debug.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		go func() {
+			log.Printf("🔬 pprof running at localhost:%d/debug/pprof/\n", pprofPort)
+			log.Println(http.ListenAndServe(fmt.Sprintf("localhost:%d", pprofPort), debug))
+		}()
+	}
+
+	`)
+	}
+
+	if opts.Realtime != "" && len(chain) == 0 {
+		method, path, realtime := realtimeRoute()
+		routes = append(routes, fmt.Sprintf(`%s.HandleFunc("%s%s", %s)`, on, method, path, realtime))
+	}
+
+	if versionedAPI() {
+		routes = append([]string{"versioned := http.NewServeMux()"}, routes...)
+		routes = append(routes, fmt.Sprintf(`mux.Handle("%[1]s/", http.StripPrefix("%[1]s", versioned))`, routePrefix()))
+	}
+
+	handler := "mux"
+	if len(chain) > 0 {
+		handler = fmt.Sprintf("middleware.Chain(mux,\n\t\t%s,\n\t)", strings.Join(chain, ",\n\t\t"))
+	}
+	if opts.Realtime != "" && len(chain) > 0 {
+		// Streams outlive the request timeout, so they skip the chain
+		method, path, realtime := realtimeRoute()
+		routes = append(routes,
+			"root := http.NewServeMux()",
+			`root.Handle("/", `+handler+`)`,
+			fmt.Sprintf(`root.HandleFunc("%s%s%s", %s)`, method, routePrefix(), path, realtime))
+		handler = "root"
+	}
+
+	std = append(std, "time")
+	mods = append(mods, project+"/shared/shutdown")
+	return goSource("main", std, mods, fmt.Sprintf(`func main() {
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	// On SIGINT/SIGTERM requests in flight get 10s to finish
+	if err := run(ctx, nil); err != nil {
+		log.Fatalf("❌ %%v", err)
+	}
+	log.Println("👋 API server stopped")
+}
+
+// run serves the API until ctx is done, on lis or else on the configured
+// port. Tests pass a listener on an ephemeral port.
+func run(ctx context.Context, lis net.Listener) error {
+%s	%s
+	config, err := %s
+	if err == nil {
+		%s
+	}
+
+	mux := http.NewServeMux()
+	%s
+
+	%sif lis == nil {
+		if lis, err = net.Listen("tcp", fmt.Sprintf(":%%d", port)); err != nil {
+			return err
+		}
+	}
+
+	tasks := shutdown.NewGroup(ctx)
+	tasks.Go(shutdown.HTTPServer(&http.Server{Handler: api.Wrap(%s)}, lis, 10*time.Second))
+	log.Printf("🔌 API server running at %%s\n", lis.Addr())
+	return tasks.Wait()
+}
+`, setup, strings.Join(vars, "\n\t"), load, strings.Join(assign, "\n\t\t"), strings.Join(routes, "\n\t"), strings.Join(before, ""), handler))
+}
+
+// greetSource renders api/greet.go (api/<version>/greet.go with
+// --api-version), a JSON POST handler demonstrating request decoding and
+// validation. With --validation the checks are declared as struct tags
+// and run by shared/validate.
+func greetSource(project, service string) string {
+	return greetHandlerSource(project, handlersPkg(),
+		[]string{serviceImport(project, service, "internal/service")},
+		"GreetHandler(w http.ResponseWriter, r *http.Request)",
+		`greeting, err := service.Greet(r.Context(), req.Name)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}`)
+}
+
+// greetHandlerSource renders a GreetRequest handler with the given signature
+// in package pkg; greet must set greeting from req.Name
+func greetHandlerSource(project, pkg string, mods []string, signature, greet string) string {
+	std := []string{"encoding/json", "net/http"}
+	mods = append(mods, project+"/shared/apierror")
+
+	nameTag := `§json:"name"§`
+	validate := `// validate reports the first invalid field of the request
+func (req GreetRequest) validate() error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > 100 {
+		return errors.New("name must be at most 100 characters")
+	}
+	return nil
+}`
+	call := "req.validate()"
+	if opts.Validation {
+		mods = append(mods, project+"/shared/validate")
+		nameTag = `§json:"name" validate:"required,max=100"§`
+		validate = ""
+		call = "validate.Validate(req)"
+	} else {
+		std = append(std, "errors", "strings")
+	}
+
+	name, _, _ := strings.Cut(signature, "(")
+	if recv, method, ok := strings.Cut(signature, ") "); ok && strings.HasPrefix(recv, "(") {
+		name, _, _ = strings.Cut(method, "(")
+	}
+
+	return goSource(pkg, std, mods, renderTemplate(fmt.Sprintf(`// GreetRequest is the JSON body accepted by %[4]s
+type GreetRequest struct {
+	Name string %[1]s
+}
+
+// GreetResponse is the JSON body returned by %[4]s
+type GreetResponse struct {
+	Greeting string §json:"greeting"§
+}
+
+%[2]s
+
+// %[4]s decodes a GreetRequest, validates it and responds with a
+// greeting. Failures are apierror codes: invalid_request (400) for
+// malformed JSON, validation_failed (422) for invalid fields.
+func %[5]s {
+	var req GreetRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		apierror.WriteError(w, apierror.New(apierror.InvalidRequest, "invalid JSON body: "+err.Error()))
+		return
+	}
+
+	if err := %[3]s; err != nil {
+		apierror.WriteError(w, apierror.New(apierror.ValidationError, err.Error()))
+		return
+	}
+
+	%[6]s
+	writeJSON(w, http.StatusOK, GreetResponse{Greeting: greeting})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+`, nameTag, validate, call, name, signature, greet), '§'))
+}
+
+// authSource renders api/auth.go, the JWT middleware and sample protected
+// handler generated with --auth jwt
+func authSource(project, service string) string {
+	return goSource("api",
+		[]string{"encoding/json", "fmt", "net/http", "strings"},
+		[]string{"github.com/golang-jwt/jwt/v5", project + "/shared/appctx"},
+		fmt.Sprintf(`// RequireJWT rejects requests without a valid HS256 bearer token signed
+// with secret, storing the token subject as appctx.UserID. An empty secret
+// rejects everything rather than accepting tokens signed with an empty key.
+func RequireJWT(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if secret == "" {
+				unauthorized(w, "authentication is not configured")
+				return
+			}
+
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || raw == "" {
+				unauthorized(w, "missing bearer token")
+				return
+			}
+
+			token, err := jwt.Parse(raw, func(t *jwt.Token) (any, error) {
+				return []byte(secret), nil
+			}, jwt.WithValidMethods([]string{"HS256"}))
+			if err != nil || !token.Valid {
+				unauthorized(w, "invalid token")
+				return
+			}
+
+			ctx := r.Context()
+			if sub, err := token.Claims.GetSubject(); err == nil && sub != "" {
+				ctx = appctx.WithUserID(ctx, sub)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// PrivateHandler is a sample route protected by RequireJWT
+func PrivateHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "🔒 Hello %%s from the protected %s API!\n", appctx.UserID(r.Context()))
+}
+`, service))
+}
@@ -0,0 +1,77 @@
+package main
+
+// apierrorSource is shared/apierror/apierror.go, the error codes every
+// service answers with so clients can branch on them instead of messages
+var apierrorSource = renderTemplate(`// Package apierror defines the machine-readable error codes shared by the
+// services and writes them as JSON: {"error": "<message>", "code": "<code>"}
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Code identifies a kind of failure. Codes are part of the API contract:
+// add new ones rather than changing existing ones.
+type Code string
+
+const (
+	InvalidRequest  Code = "invalid_request"
+	ValidationError Code = "validation_failed"
+	Unauthorized    Code = "unauthorized"
+	Forbidden       Code = "forbidden"
+	NotFound        Code = "not_found"
+	Conflict        Code = "conflict"
+	RateLimited     Code = "rate_limited"
+	Internal        Code = "internal"
+)
+
+// statuses maps every code to its HTTP status; unknown codes are 500s
+var statuses = map[Code]int{
+	InvalidRequest:  http.StatusBadRequest,
+	ValidationError: http.StatusUnprocessableEntity,
+	Unauthorized:    http.StatusUnauthorized,
+	Forbidden:       http.StatusForbidden,
+	NotFound:        http.StatusNotFound,
+	Conflict:        http.StatusConflict,
+	RateLimited:     http.StatusTooManyRequests,
+	Internal:        http.StatusInternalServerError,
+}
+
+// Error is an error meant for API clients
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// New returns an *Error with the HTTP status of code
+func New(code Code, message string) *Error {
+	status, ok := statuses[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+// WriteError answers with err as JSON. Errors that do not wrap an *Error
+// are answered as Internal without their message, which may leak details.
+func WriteError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = New(Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(struct {
+		Error string §json:"error"§
+		Code  Code   §json:"code"§
+	}{apiErr.Message, apiErr.Code})
+}
+`, '§')
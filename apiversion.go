@@ -0,0 +1,38 @@
+package main
+
+import "regexp"
+
+// apiVersionPattern matches the versions --api-version accepts
+var apiVersionPattern = regexp.MustCompile(`^v\d+$`)
+
+// versionedAPI reports whether the sample routes are nested under
+// /api/<version>, with --api-version
+func versionedAPI() bool {
+	return opts.APIVersion != ""
+}
+
+// handlersRel returns the package of the sample handlers relative to the
+// service: api, or api/<version> with --api-version
+func handlersRel() string {
+	if versionedAPI() {
+		return "api/" + opts.APIVersion
+	}
+	return "api"
+}
+
+// handlersPkg returns the package name of the sample handlers
+func handlersPkg() string {
+	if versionedAPI() {
+		return opts.APIVersion
+	}
+	return "api"
+}
+
+// routePrefix returns the path the versioned routes are served under, or
+// "" for unversioned routes
+func routePrefix() string {
+	if versionedAPI() {
+		return "/api/" + opts.APIVersion
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package main
+
+// appctxSource is shared/appctx/appctx.go: typed context keys for the
+// request-scoped values set by the middleware, so services never pass
+// string keys to context.WithValue
+var appctxSource = formatGo(`// Package appctx stores request-scoped values in a context.Context under
+// unexported typed keys, so they cannot collide with other packages' keys.
+// Use the With* setters and getters instead of context.WithValue.
+package appctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+type key int
+
+const (
+	requestIDKey key = iota
+	userIDKey
+	loggerKey
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID stored in ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserID returns the authenticated user ID stored in ctx, or "" for
+// anonymous requests
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey).(string)
+	return id
+}
+
+// WithLogger returns a copy of ctx carrying a request-scoped logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// Logger returns the logger stored in ctx, falling back to slog.Default()
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+`)
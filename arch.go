@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// With --arch clean the flat internal/service package is replaced by
+// Clean Architecture layers, dependencies pointing inwards:
+//
+//	internal/entity       domain types, no dependencies
+//	internal/usecase      business rules and the repository interface they need
+//	internal/repository   implementations of the usecase interfaces
+//	delivery/http         HTTP handlers depending on a usecase interface
+//
+// The API, CLI and gRPC entrypoints wire the layers together.
+
+// cleanArch reports whether services use the --arch clean layers
+func cleanArch() bool {
+	return opts.Arch == "clean"
+}
+
+// layeredArch reports whether the handlers are delivery/http methods with
+// the greeter injected by the entrypoints, with --arch clean or --ddd
+func layeredArch() bool {
+	return cleanArch() || dddArch()
+}
+
+// serviceCorePackage returns the innermost package of a service, created
+// with the default directories
+func serviceCorePackage(service string) string {
+	switch {
+	case cleanArch():
+		return "internal/usecase"
+	case dddArch():
+		return "internal/" + aggregate(service)
+	}
+	return "internal/service"
+}
+
+// newGreeter returns the expression building the greeter in entrypoints
+func newGreeter(service string) string {
+	if dddArch() {
+		return fmt.Sprintf("%[1]s.NewService(%[1]s.NewMemoryRepository())", aggregate(service))
+	}
+	return "usecase.NewGreetUsecase(repository.NewMemoryGreetingRepository())"
+}
+
+// greeterImports returns the packages newGreeter needs
+func greeterImports(project, service string) []string {
+	if dddArch() {
+		return []string{serviceImport(project, service, "internal/"+aggregate(service))}
+	}
+	return []string{
+		serviceImport(project, service, "internal/repository"),
+		serviceImport(project, service, "internal/usecase"),
+	}
+}
+
+// greetingType returns the type the greeter returns, with the package
+// declaring it
+func greetingType(project, service string) (string, string) {
+	if dddArch() {
+		return aggregate(service) + "." + aggregateRoot(service), serviceImport(project, service, "internal/"+aggregate(service))
+	}
+	return "entity.Greeting", serviceImport(project, service, "internal/entity")
+}
+
+// createCleanArch writes the entity, usecase, repository and delivery/http
+// packages of a service
+func createCleanArch(project, service string) {
+	entity := serviceImport(project, service, "internal/entity")
+
+	writeFile(servicePackage(project, service, "internal/entity"), "greeting.go", goSource("entity",
+		[]string{"time"},
+		nil,
+		`// Greeting is a greeting addressed to someone
+type Greeting struct {
+	Name      string
+	Message   string
+	CreatedAt time.Time
+}
+`))
+
+	writeFile(servicePackage(project, service, "internal/usecase"), "greet.go", goSource("usecase",
+		[]string{"context", "time"},
+		[]string{entity},
+		`// GreetingRepository stores greetings. The use case owns the interface;
+// the repository package provides implementations.
+type GreetingRepository interface {
+	Save(ctx context.Context, greeting entity.Greeting) error
+}
+
+// GreetUsecase greets people and records every greeting
+type GreetUsecase struct {
+	repo GreetingRepository
+}
+
+func NewGreetUsecase(repo GreetingRepository) *GreetUsecase {
+	return &GreetUsecase{repo: repo}
+}
+
+// Greet builds a greeting for name and saves it
+func (u *GreetUsecase) Greet(ctx context.Context, name string) (entity.Greeting, error) {
+	greeting := entity.Greeting{Name: name, Message: "👋 Hello " + name, CreatedAt: time.Now()}
+	if err := u.repo.Save(ctx, greeting); err != nil {
+		return entity.Greeting{}, err
+	}
+	return greeting, nil
+}
+`))
+
+	writeFile(servicePackage(project, service, "internal/repository"), "memory.go", goSource("repository",
+		[]string{"context", "sync"},
+		[]string{entity},
+		`// MemoryGreetingRepository keeps greetings in memory. Replace it with a
+// database-backed usecase.GreetingRepository.
+type MemoryGreetingRepository struct {
+	mu        sync.Mutex
+	greetings []entity.Greeting
+}
+
+func NewMemoryGreetingRepository() *MemoryGreetingRepository {
+	return &MemoryGreetingRepository{}
+}
+
+func (r *MemoryGreetingRepository) Save(ctx context.Context, greeting entity.Greeting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.greetings = append(r.greetings, greeting)
+	return nil
+}
+`))
+
+	createDelivery(project, service)
+
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Layers") {
+		appendContent(readme, fmt.Sprintf(`
+## Layers
+
+    %-21s domain types
+    %-21s business rules, declaring the repositories they need
+    %-21s repository implementations (in-memory to start)
+    %-21s HTTP handlers, depending on use case interfaces
+
+Dependencies point inwards: use cases declare the interfaces they need and
+outer layers satisfy them, never the other way round. cmd/ wires the layers
+together.
+`, packageRel("internal/entity"), packageRel("internal/usecase"), packageRel("internal/repository"), "delivery/http"))
+	}
+}
+
+// createDelivery writes the delivery/http handlers of --arch clean and
+// --ddd, depending on the greeter the entrypoints inject
+func createDelivery(project, service string) {
+	greeting, greetingMod := greetingType(project, service)
+	deliveryDir := servicePackage(project, service, "delivery/http")
+	writeFile(deliveryDir, "handler.go", goSource("httpdelivery",
+		[]string{"context", "fmt", "net/http"},
+		[]string{greetingMod},
+		fmt.Sprintf(`// Greeter is the use case the handlers depend on
+type Greeter interface {
+	Greet(ctx context.Context, name string) (%[2]s, error)
+}
+
+// Handler serves the %[1]s HTTP routes
+type Handler struct {
+	greeter Greeter
+}
+
+func NewHandler(greeter Greeter) *Handler {
+	return &Handler{greeter: greeter}
+}
+
+// Hello greets the name query parameter
+func (h *Handler) Hello(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		fmt.Fprintln(w, "👋 Hello from the %[1]s API!")
+		return
+	}
+
+	greeting, err := h.greeter.Greet(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintln(w, greeting.Message+"!")
+}
+`, service, greeting)))
+
+	writeFile(deliveryDir, "greet.go", greetHandlerSource(project, "httpdelivery", nil,
+		"(h *Handler) Greet(w http.ResponseWriter, r *http.Request)",
+		`result, err := h.greeter.Greet(r.Context(), req.Name)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	greeting := result.Message`))
+}
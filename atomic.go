@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// atomicEnv marks the child run of --atomic and holds the directory the
+// tool was started from, where .creategorc is looked up
+const atomicEnv = "CREATE_GO_PROJECT_ATOMIC_FROM"
+
+// atomicChild reports whether this run generates into the temporary
+// directory of a parent --atomic run
+func atomicChild() bool {
+	return os.Getenv(atomicEnv) != ""
+}
+
+// checkAtomic validates --atomic, which renames the generated tree from a
+// directory next to the project into place
+func checkAtomic(project string) {
+	if !opts.Atomic {
+		return
+	}
+	if !filepath.IsLocal(project) {
+		log.Fatalf("❌ --atomic needs a project path inside the current directory, got %q.", project)
+	}
+	if opts.WorkspaceRoot != "" {
+		log.Fatal("❌ --atomic generates away from the project directory and cannot be combined with --workspace-root.")
+	}
+}
+
+// generateAtomically runs the tool again in a temporary directory of the
+// current one, with the resolved options, and renames the project into
+// place only when that run succeeds. The project is then either complete
+// or absent, even when the run stops on a fatal error.
+func generateAtomically(project string, services []string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	tmp, err := os.MkdirTemp(".", ".create-go-project-")
+	if err != nil {
+		log.Fatalf("❌ Failed to create the temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	cmd := exec.Command(exe, atomicArgs(project, services)...)
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), atomicEnv+"="+cwd)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	debugf("atomic: generating %s in %s", project, tmp)
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(tmp)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			fmt.Fprintf(os.Stderr, "🗑️  Discarded the partial project; %s was not created\n", project)
+			os.Exit(exitErr.ExitCode())
+		}
+		log.Fatalf("❌ Failed to run the generation: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(project), 0755); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := os.Rename(filepath.Join(tmp, project), project); err != nil {
+		os.RemoveAll(tmp)
+		log.Fatalf("❌ Failed to move the project into place: %v", err)
+	}
+	fmt.Println("📦 Moved the complete project into", project)
+}
+
+// atomicArgs returns the command line of the child run: the project, its
+// services and every option with its resolved value, so profiles, the
+// wizard and derived defaults apply exactly as in this run
+func atomicArgs(project string, services []string) []string {
+	args := []string{project}
+	skip := map[string]bool{"atomic": true, "wizard": true, "profile": true, "show-config": true, "service": true}
+	flag.VisitAll(func(f *flag.Flag) {
+		if skip[f.Name] {
+			return
+		}
+		switch value := f.Value.(type) {
+		case *envList:
+			for _, v := range *value {
+				args = append(args, "-"+f.Name+"="+v)
+			}
+		case *moduleList:
+			for _, v := range *value {
+				args = append(args, "-"+f.Name+"="+v)
+			}
+		default:
+			if f.Name == "layout-file" && opts.LayoutFile != "" {
+				args = append(args, "-layout-file="+absPath(opts.LayoutFile))
+				return
+			}
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return append(args, "-service="+strings.Join(services, ","))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	goversion "go/version"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// goVersionArg matches the versions bump-go accepts: a language version,
+// optionally with its patch release
+var goVersionArg = regexp.MustCompile(`^1\.\d+(?:\.\d+)?$`)
+
+// goDirective and toolchainDirective match the go and toolchain lines of
+// a go.mod or go.work
+var (
+	goDirective        = regexp.MustCompile(`(?m)^go (\S+)`)
+	toolchainDirective = regexp.MustCompile(`(?m)^toolchain (\S+)`)
+)
+
+// goReference is a mention of the Go version outside go.mod and go.work:
+// match captures the text before the version, replaced after it
+type goReference struct {
+	applies func(rel string) bool
+	match   *regexp.Regexp
+}
+
+// goReferences lists the generated files naming the Go version
+var goReferences = []goReference{
+	{
+		applies: func(rel string) bool { return filepath.Base(rel) == "Dockerfile" },
+		match:   regexp.MustCompile(`(?m)^(FROM golang:)[\d.]+`),
+	},
+	{
+		applies: func(rel string) bool { return rel == ".gitlab-ci.yml" },
+		match:   regexp.MustCompile(`(?m)^(image: golang:)[\d.]+`),
+	},
+	{
+		// A matrix of versions, as --ci-matrix writes, is left alone
+		applies: func(rel string) bool {
+			return strings.HasPrefix(filepath.ToSlash(rel), ".github/workflows/") && (strings.HasSuffix(rel, ".yml") || strings.HasSuffix(rel, ".yaml"))
+		},
+		match: regexp.MustCompile(`(?m)^([ \t]+go-version:[ \t]*"?)[\d.]+`),
+	},
+}
+
+// bumpGo moves every module of the project, its go.work and the CI and
+// Dockerfile references to Go version, then syncs the workspace. With
+// --toolchain the modules and go.work also pin that toolchain.
+func bumpGo(project, version string) {
+	if !goVersionArg.MatchString(version) {
+		log.Fatalf("❌ Invalid Go version %q, expected a version such as 1.23 or 1.23.4.", version)
+	}
+	if opts.Toolchain != "" && (!strings.HasPrefix(opts.Toolchain, "go") || !goversion.IsValid(opts.Toolchain)) {
+		log.Fatalf("❌ Invalid --toolchain %q, expected a toolchain name such as go1.23.4.", opts.Toolchain)
+	}
+	if opts.Toolchain != "" && goversion.Compare(opts.Toolchain, "go"+version) < 0 {
+		log.Fatalf("❌ --toolchain %s is older than Go %s; the go command would reject it.", opts.Toolchain, version)
+	}
+	if _, err := os.Stat(project); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if goversion.Compare("go"+version, "go"+goPatchVer) > 0 {
+		warnf("Go %s is newer than the installed Go %s: the go command will download a matching toolchain", version, goPatchVer)
+	}
+
+	var modules, others []string
+	err := filepath.WalkDir(project, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != project && (d.Name() == "vendor" || d.Name() == "node_modules" || d.Name() == ".git") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.mod" {
+			modules = append(modules, filepath.Dir(path))
+		} else {
+			others = append(others, path)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to list the files of %s: %v", project, err)
+	}
+	if len(modules) == 0 {
+		log.Fatalf("❌ %s has no go.mod to bump.", project)
+	}
+
+	changed := 0
+	edit := func(path string, run func() error) {
+		before, _ := os.ReadFile(path)
+		if err := run(); err != nil {
+			warnf("Failed to update %s: %v", path, err)
+			return
+		}
+		if after, _ := os.ReadFile(path); string(after) != string(before) {
+			fmt.Println("🔄 Updated:", path)
+			changed++
+		}
+	}
+	editArgs := func(kind, path string) []string {
+		args := []string{kind, "edit", "-go=" + version}
+		current := opts.Toolchain
+		if current == "" {
+			// A toolchain older than the go line is invalid
+			data, _ := os.ReadFile(path)
+			if m := toolchainDirective.FindSubmatch(data); m != nil && goversion.Compare(string(m[1]), "go"+version) < 0 {
+				current = "none"
+			}
+		}
+		if current != "" {
+			args = append(args, "-toolchain="+current)
+		}
+		return args
+	}
+
+	// The workspace must not be older than its modules: only ever raise it
+	work := filepath.Join(workspaceDir(project), "go.work")
+	if data, err := os.ReadFile(work); err == nil {
+		m := goDirective.FindSubmatch(data)
+		if m == nil || goversion.Compare("go"+string(m[1]), "go"+version) < 0 || opts.Toolchain != "" {
+			edit(work, func() error { return runCmd(filepath.Dir(work), "go", editArgs("work", work)...) })
+		}
+	}
+	for _, dir := range modules {
+		path := filepath.Join(dir, "go.mod")
+		edit(path, func() error { return runCmd(dir, "go", editArgs("mod", path)...) })
+	}
+
+	// Image tags and setup-go name the language version, without the patch
+	short := version
+	if parts := strings.SplitN(version, ".", 3); len(parts) == 3 {
+		short = parts[0] + "." + parts[1]
+	}
+	for _, path := range others {
+		rel, _ := filepath.Rel(project, path)
+		for _, ref := range goReferences {
+			if !ref.applies(rel) {
+				continue
+			}
+			edit(path, func() error {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				content := ref.match.ReplaceAllString(string(data), "${1}"+short)
+				if content == string(data) {
+					return nil
+				}
+				return os.WriteFile(path, []byte(content), 0644)
+			})
+		}
+	}
+
+	if _, err := os.Stat(work); err == nil {
+		if err := runCmd(filepath.Dir(work), "go", "work", "sync"); err != nil {
+			warnf("Failed to run go work sync in %s: %v", filepath.Dir(work), err)
+		} else {
+			fmt.Println("🔗 go work sync run inside", filepath.Dir(work))
+		}
+	}
+
+	if changed == 0 {
+		fmt.Printf("\n✔️  %s already uses Go %s\n", project, version)
+		return
+	}
+	fmt.Printf("\n✅ Moved %s to Go %s in %d files\n", project, version, changed)
+}
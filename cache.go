@@ -0,0 +1,79 @@
+package main
+
+// cacheSource renders shared/cache/cache.go, the Redis client wrapper
+// generated with --cache redis
+func cacheSource() string {
+	return goSource("cache",
+		[]string{"context", "errors", "time"},
+		[]string{"github.com/redis/go-redis/v9"},
+		`// Cache stores string values in Redis
+type Cache struct {
+	client *redis.Client
+}
+
+// New returns a Cache for the Redis server at addr. Connections are opened
+// lazily, so New succeeds even when Redis is down; use Ping to check it.
+func New(addr, password string, db int) *Cache {
+	return &Cache{client: redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})}
+}
+
+// Get returns the value stored at key; found is false when it is missing
+func (c *Cache) Get(ctx context.Context, key string) (value string, found bool, err error) {
+	value, err = c.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value at key, expiring after ttl (0 keeps it forever)
+func (c *Cache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Ping checks that Redis is reachable, for readiness probes
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+func (c *Cache) Close() error {
+	return c.client.Close()
+}
+`)
+}
+
+// readySource renders api/ready.go, the /readyz handler checking the
+// service dependencies
+func readySource() string {
+	return goSource("api",
+		[]string{"context", "encoding/json", "net/http", "time"},
+		nil,
+		`// ReadyHandler answers 200 when every check passes and 503 otherwise,
+// reporting the status of each dependency by name
+func ReadyHandler(checks map[string]func(context.Context) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		status, results := http.StatusOK, make(map[string]string, len(checks))
+		for name, check := range checks {
+			results[name] = "ok"
+			if err := check(ctx); err != nil {
+				status, results[name] = http.StatusServiceUnavailable, err.Error()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(results)
+	}
+}
+`)
+}
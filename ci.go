@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	goversion "go/version"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// createCI writes the pipeline for the CI system chosen with --ci
+func createCI(project string) {
+	switch opts.CI {
+	case "gitlab":
+		writeFile(project, ".gitlab-ci.yml", gitlabCI())
+	}
+	if opts.CIMatrix != "" {
+		writeFile(filepath.Join(project, ".github", "workflows"), "go-matrix.yml", matrixWorkflow())
+	}
+}
+
+// ciMatrixVersions returns the Go versions of --ci-matrix
+func ciMatrixVersions() []string {
+	var versions []string
+	for _, v := range strings.Split(opts.CIMatrix, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// checkCIMatrix validates --ci-matrix. The workflow pins each toolchain, so
+// a version older than the go directive could never build the modules.
+func checkCIMatrix() {
+	if opts.CIMatrix == "" {
+		return
+	}
+	versions := ciMatrixVersions()
+	if len(versions) == 0 {
+		log.Fatal("❌ --ci-matrix needs at least one Go version, such as 1.22,1.23.")
+	}
+	for _, v := range versions {
+		if !majorMinor.MatchString(v) {
+			log.Fatalf("❌ Invalid --ci-matrix version %q, expected a major.minor version such as 1.22.", v)
+		}
+		if goversion.Compare("go"+v, "go"+modGoVersion()) < 0 {
+			log.Fatalf("❌ --ci-matrix version %s is older than the go.mod version %s; lower it with --go-directive.", v, modGoVersion())
+		}
+	}
+}
+
+// eachModule returns a shell command running cmd in every module with
+// GOWORK=off, matching how each service module resolves shared via
+// replace, or once at the root of a single module
+func eachModule(cmd string) string {
+	if opts.SingleModule {
+		return cmd
+	}
+	return fmt.Sprintf(`for dir in shared services/*/; do (cd "$dir" && GOWORK=off %s) || exit 1; done`, cmd)
+}
+
+// matrixWorkflow renders the scheduled GitHub Actions workflow of
+// --ci-matrix, building and testing every module with each Go version.
+// GOTOOLCHAIN=local keeps the go command from switching to a newer
+// toolchain, so a job fails when its version no longer works.
+func matrixWorkflow() string {
+	return fmt.Sprintf(`# Checks weekly that the project still builds and passes its tests with
+# every supported Go version, catching toolchain regressions between changes
+name: go-matrix
+
+on:
+  schedule:
+    - cron: "0 6 * * 1"
+  workflow_dispatch:
+
+permissions:
+  contents: read
+
+jobs:
+  build:
+    name: Go ${{ matrix.go }}
+    runs-on: ubuntu-latest
+    strategy:
+      fail-fast: false
+      matrix:
+        go: [%s]
+    env:
+      GOTOOLCHAIN: local
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: ${{ matrix.go }}
+          check-latest: true
+      - name: Build
+        run: %s
+      - name: Test
+        run: %s
+`, `"`+strings.Join(ciMatrixVersions(), `", "`)+`"`, eachModule("go build ./..."), eachModule("go test ./..."))
+}
+
+// gitlabCI renders .gitlab-ci.yml, checking every module on its own
+func gitlabCI() string {
+	return fmt.Sprintf(`image: golang:%[1]s
+
+variables:
+  GOPATH: $CI_PROJECT_DIR/.go
+  GOCACHE: $CI_PROJECT_DIR/.cache/go-build
+
+cache:
+  key: go-$CI_COMMIT_REF_SLUG
+  paths:
+    - .go/pkg/mod/
+    - .cache/go-build/
+
+stages:
+  - build
+  - test
+  - lint
+
+build:
+  stage: build
+  script:
+    - %[2]s
+
+test:
+  stage: test
+  script:
+    - %[3]s
+
+lint:
+  stage: lint
+  image: golangci/golangci-lint:latest
+  script:
+    - %[4]s
+`, goVer, eachModule("go build ./..."), eachModule("go test ./..."), eachModule("golangci-lint run ./..."))
+}
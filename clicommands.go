@@ -0,0 +1,74 @@
+package main
+
+import "fmt"
+
+// With --config-commands the service config declares extra CLI commands,
+// each running a program, registered with cobra when the CLI starts:
+//
+//	commands:
+//	  - name: goenv
+//	    short: Print the Go environment, all of it or the variables named
+//	    run: [go, env]
+//
+// cli goenv GOOS runs go env GOOS: arguments follow those of run.
+
+// sampleCommand is the command declared in the generated configs
+var sampleCommand = struct{ name, short string }{"goenv", "Print the Go environment, all of it or the variables named"}
+
+// commandTypeSource declares Command in shared/config
+const commandTypeSource = `
+// Command is a CLI command declared in config: the CLI runs the program
+// and arguments of Run, followed by those given on the command line
+type Command struct {
+	Name  string   §yaml:"name"§
+	Short string   §yaml:"short"§
+	Run   []string §yaml:"run"§
+}
+`
+
+// configCommandsSource renders cli/commands.go, registering the commands
+// of the service config next to the built-in ones
+func configCommandsSource(project, service string) string {
+	load, loadMods := loadConfigCall(project, service)
+	return goSource("cli",
+		[]string{"fmt", "os", "os/exec"},
+		append([]string{"github.com/spf13/cobra", project + "/shared/config"}, loadMods...),
+		fmt.Sprintf(`// addConfigCommands registers a command per entry of commands in the
+// service config, so the CLI is extended without recompiling. It runs from
+// Execute, once the built-in commands are registered: a config command
+// never replaces one of them.
+func addConfigCommands() {
+	config, err := %s
+	if err != nil || len(config.Commands) == 0 {
+		// The built-in commands work without config
+		return
+	}
+
+	builtin := map[string]bool{"help": true, "completion": true}
+	for _, cmd := range rootCmd.Commands() {
+		builtin[cmd.Name()] = true
+	}
+	rootCmd.AddGroup(&cobra.Group{ID: "config", Title: "Commands from config:"})
+	for _, c := range config.Commands {
+		if c.Name == "" || len(c.Run) == 0 || builtin[c.Name] {
+			fmt.Fprintf(os.Stderr, "⚠️ Skipped config command %%q: it needs a name and a run program, and must not shadow a built-in command\n", c.Name)
+			continue
+		}
+		builtin[c.Name] = true
+		rootCmd.AddCommand(&cobra.Command{
+			Use:     c.Name + " [args...]",
+			Short:   c.Short,
+			GroupID: "config",
+			// Flags belong to the program, not to the CLI
+			DisableFlagParsing: true,
+			SilenceUsage:       true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				run := exec.CommandContext(cmd.Context(), c.Run[0], append(c.Run[1:], args...)...)
+				run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+				return run.Run()
+			},
+		})
+	}
+}
+`, load))
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// createClient writes the service's typed HTTP client package, one method
+// per sample endpoint, for other services to import
+func createClient(project, service string, port int) {
+	writeFile(servicePackage(project, service, "client"), "client.go", goSource("client",
+		[]string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "strconv", "strings", "time"},
+		[]string{project + "/shared/pagination"},
+		renderTemplate(fmt.Sprintf(`// DefaultBaseURL is where the %[1]s API listens by default
+const DefaultBaseURL = "http://localhost:%[2]d"
+
+// Client calls the %[1]s API. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// New returns a client for the %[1]s API at baseURL, e.g. DefaultBaseURL
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Error is a non-2xx answer of the API. Code is the shared/apierror code,
+// empty when the answer carried none.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%[1]s API: %%d %%s", e.StatusCode, e.Message)
+}
+
+// Item is an entry of ListItems
+type Item struct {
+	ID   int    §json:"id"§
+	Name string §json:"name"§
+}
+
+// Hello calls GET %[3]s/hello, greeting name or the service when name is empty
+func (c *Client) Hello(ctx context.Context, name string) (string, error) {
+	path := "%[3]s/hello"
+	if name != "" {
+		path += "?name=" + url.QueryEscape(name)
+	}
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return strings.TrimSpace(string(body)), err
+}
+
+// Greet calls POST %[3]s/greet
+func (c *Client) Greet(ctx context.Context, name string) (string, error) {
+	var out struct {
+		Greeting string §json:"greeting"§
+	}
+	err := c.doJSON(ctx, http.MethodPost, "%[3]s/greet", map[string]string{"name": name}, &out)
+	return out.Greeting, err
+}
+
+// Version calls GET /version and returns the build metadata
+func (c *Client) Version(ctx context.Context) (map[string]string, error) {
+	var out map[string]string
+	err := c.doJSON(ctx, http.MethodGet, "/version", nil, &out)
+	return out, err
+}
+
+// ListItems calls GET %[3]s/items for the page at offset; page.Next is the
+// offset of the following page, nil on the last one
+func (c *Client) ListItems(ctx context.Context, limit, offset int) (pagination.Page[Item], error) {
+	var page pagination.Page[Item]
+	query := url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(offset)}}
+	err := c.doJSON(ctx, http.MethodGet, "%[3]s/items?"+query.Encode(), nil, &page)
+	return page, err
+}
+
+// doJSON sends in as the JSON body, when not nil, and decodes the answer into out
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	resp, err := c.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends a request and turns non-2xx answers into an *Error
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var payload struct {
+			Error string §json:"error"§
+			Code  string §json:"code"§
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if json.Unmarshal(data, &payload) == nil && payload.Error != "" {
+			apiErr.Code = payload.Code
+			apiErr.Message = payload.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+`, service, port, routePrefix()), '§')))
+
+	writeFile(servicePackage(project, service, "cli"), "call.go", callCmdSource(project, service))
+
+	resolution := `
+go.work resolves the import in other services of the workspace. Builds
+outside the workspace need a require and a replace pointing at this module.
+`
+	if goWorkOff() {
+		resolution = `
+Other service modules need a require and a replace pointing at this module.
+`
+	}
+	if opts.SingleModule {
+		resolution = ""
+	}
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Client") {
+		appendContent(readme, fmt.Sprintf(`
+## Client
+
+%[1]s is a typed client of this API for other services:
+
+    c := client.New(client.DefaultBaseURL)
+    greeting, err := c.Greet(ctx, "gopher")
+
+The CLI uses it in %[3]s, which reads the API port from config. From the
+project root, with the API running:
+
+    %[4]s call gopher
+%[2]s`, "`"+serviceImport(project, service, "client")+"`", resolution, "`cli/call.go`", goRunCmd(service, "cli")))
+	}
+}
+
+// callCmdSource renders cli/call.go, a CLI command calling the running API
+// through the client package, with the context cancelled on Ctrl-C
+func callCmdSource(project, service string) string {
+	load, loadMods := loadConfigCall(project, service)
+	return goSource("cli",
+		[]string{"fmt"},
+		append([]string{"github.com/spf13/cobra", project + "/shared/config", serviceImport(project, service, "client")}, loadMods...),
+		fmt.Sprintf(`var callURL string
+
+var callCmd = &cobra.Command{
+	Use:   "call [name]",
+	Short: "Call the running API's %[2]s/hello through the client package",
+	Args:  cobra.MaximumNArgs(1),
+	// Execute reports the error once, without the usage
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		baseURL := callURL
+		if baseURL == "" {
+			baseURL = client.DefaultBaseURL
+			if config, err := %[1]s; err == nil {
+				baseURL = fmt.Sprintf("http://localhost:%%d", config.Server.Port)
+			}
+		}
+
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		greeting, err := client.New(baseURL).Hello(cmd.Context(), name)
+		if err != nil {
+			return err
+		}
+		fmt.Println(greeting)
+		return nil
+	},
+}
+
+func init() {
+	callCmd.Flags().StringVar(&callURL, "url", "", "API base URL (default http://localhost:<server.port>)")
+	rootCmd.AddCommand(callCmd)
+}
+`, load, routePrefix()))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// createCompose adds the service to docker-compose.yml, creating the file
+// with a postgres service on first use. Postgres keeps its data in a named
+// volume and services wait for its healthcheck before starting. Services are
+// the last top-level block so new ones can be appended.
+func createCompose(project, service string, port int) {
+	composePath := filepath.Join(project, "docker-compose.yml")
+	if _, err := os.Stat(composePath); os.IsNotExist(err) {
+		writeFile(project, "docker-compose.yml", fmt.Sprintf(`volumes:
+  postgres-data:
+
+services:
+  postgres:
+    image: postgres:17
+    environment:
+      POSTGRES_USER: postgres
+      POSTGRES_PASSWORD: postgres
+      POSTGRES_DB: %[1]s
+    ports:
+      - "5432:5432"
+    volumes:
+      - postgres-data:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres -d %[1]s"]
+      interval: 5s
+      timeout: 5s
+      retries: 10
+`, project))
+	}
+
+	if fileContainsText(composePath, fmt.Sprintf("\n  %s:\n", service)) {
+		return
+	}
+
+	environment := ""
+	if opts.EnvPrefix != "" {
+		environment = fmt.Sprintf(`
+    environment:
+      %s: postgres`, envVar("database.host"))
+	}
+	appendContent(composePath, fmt.Sprintf(`
+  %[1]s:
+    build:
+      context: .
+      dockerfile: %[2]s/Dockerfile
+    ports:
+      - "%[3]d:%[3]d"%[4]s
+    depends_on:
+      postgres:
+        condition: service_healthy
+`, service, filepath.ToSlash(serviceRel(service)), port, environment))
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const configTpl = `package config
+
+%[3]s
+
+// Config holds a section per feature the project was generated with
+type Config struct {
+	%[1]s
+}
+
+%[2]s
+%[5]s
+%[4]s`
+
+// fileLoaderTpl reads a service's config/config.yaml; %[2]s applies the
+// environment overrides
+const fileLoaderTpl = `func LoadConfig(service string) (*Config, error) {
+	data, err := os.ReadFile("./%[1]s/" + service + "/config/config.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, err
+	}%[2]s
+	return &config, nil
+}`
+
+// codeLoader replaces LoadConfig with --config code: services compile their
+// defaults in and only the environment is read
+const codeLoader = `// Load returns a copy of defaults, usually a service's DefaultConfig(),
+// with the environment overrides applied. No file is read.
+func Load(defaults *Config) (*Config, error) {
+	config := *defaults
+	if err := applyEnv(reflect.ValueOf(&config).Elem(), envPrefix); err != nil {
+		return nil, err
+	}%s
+	return &config, nil
+}`
+
+// configSource renders shared/config/config.go
+func configSource(project string) string {
+	var server []string
+	if opts.Transport == "grpc" {
+		server = append(server, `GRPCPort int §yaml:"grpcPort"§`)
+	}
+	if opts.Transport == "graphql" {
+		server = append(server, `GraphQLPort int §yaml:"graphqlPort"§`)
+	}
+	if opts.Auth == "jwt" {
+		server = append(server, `JWTSecret string §yaml:"jwtSecret"§`)
+	}
+
+	var blocks []string
+	if usesDatabase() {
+		blocks = append(blocks, `Database struct {
+		Driver          string        §yaml:"driver"§
+		Host            string        §yaml:"host"§
+		Port            int           §yaml:"port"§
+		User            string        §yaml:"user"§
+		Password        string        §yaml:"password"§
+		Dbname          string        §yaml:"dbname"§
+		Sslmode         string        §yaml:"sslmode"§
+		MaxOpenConns    int           §yaml:"maxOpenConns"§
+		MaxIdleConns    int           §yaml:"maxIdleConns"§
+		ConnMaxLifetime time.Duration §yaml:"connMaxLifetime"§
+	} §yaml:"database"§`)
+	}
+	if opts.TimeoutMiddleware {
+		blocks = append(blocks, `Context struct {
+		Timeout time.Duration §yaml:"timeout"§
+	} §yaml:"context"§`)
+	}
+	blocks = append(blocks, fmt.Sprintf(`Server struct {
+		Port int §yaml:"port"§%s
+	} §yaml:"server"§`, strings.Join(append([]string{""}, server...), "\n\t\t")))
+	if opts.RateLimit {
+		blocks = append(blocks, `RateLimit struct {
+		RequestsPerSecond float64 §yaml:"requestsPerSecond"§
+		Burst             int     §yaml:"burst"§
+	} §yaml:"rateLimit"§`)
+	}
+	if opts.Messaging == "nats" {
+		blocks = append(blocks, `Messaging struct {
+		URL string §yaml:"url"§
+	} §yaml:"messaging"§`)
+	}
+	if opts.Cache == "redis" {
+		blocks = append(blocks, `Cache struct {
+		Addr     string §yaml:"addr"§
+		Password string §yaml:"password"§
+		DB       int    §yaml:"db"§
+	} §yaml:"cache"§`)
+	}
+	if opts.Pprof {
+		blocks = append(blocks, `Pprof struct {
+		Enabled bool §yaml:"enabled"§
+		Port    int  §yaml:"port"§
+	} §yaml:"pprof"§`)
+	}
+	if opts.FeatureFlags {
+		blocks = append(blocks, `Features map[string]bool §yaml:"features"§`)
+	}
+	if opts.ConfigCommands {
+		blocks = append(blocks, `Commands []Command §yaml:"commands"§`)
+	}
+
+	std, mods := []string{"os"}, []string(nil)
+	if usesDatabase() || opts.TimeoutMiddleware {
+		std = append(std, "time")
+	}
+	load, funcs := "", ""
+	if opts.EnvPrefix != "" {
+		std = append(std, "fmt", "reflect", "strconv", "strings", "time")
+		load = `
+	if err := applyEnv(reflect.ValueOf(&config).Elem(), envPrefix); err != nil {
+		return nil, err
+	}`
+		funcs = fmt.Sprintf(envOverlaySource, opts.EnvPrefix)
+	}
+	if opts.ConfigCommands {
+		funcs = commandTypeSource + funcs
+	}
+	validate, validateFunc := "", ""
+	if opts.ConfigValidate {
+		std = append(std, "fmt", "strings")
+		validate = `
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}`
+		validateFunc = configValidateSource()
+	}
+	loader := fmt.Sprintf(codeLoader, validate)
+	if protobufConfig() {
+		var protoStd, protoMods []string
+		loader, protoStd, protoMods = protoLoaderSource(project, load+validate)
+		std, mods = append(std, protoStd...), append(mods, protoMods...)
+	} else if !configInCode() {
+		mods = append(mods, "gopkg.in/yaml.v2")
+		loader = fmt.Sprintf(fileLoaderTpl, servicesRel(), load+validate)
+	}
+	return formatGo(renderTemplate(fmt.Sprintf(configTpl, strings.Join(blocks, "\n\t"), loader, importBlock(std, mods), funcs, validateFunc), '§'))
+}
+
+// configValidateSource renders Config.Validate for --config-validate-on-load,
+// checking the fields the enabled features rely on
+func configValidateSource() string {
+	checks := []string{`port("server.port", c.Server.Port)`}
+	if opts.Transport == "grpc" {
+		checks = append(checks, `port("server.grpcPort", c.Server.GRPCPort)`)
+	}
+	if opts.Transport == "graphql" {
+		checks = append(checks, `port("server.graphqlPort", c.Server.GraphQLPort)`)
+	}
+	if opts.Auth == "jwt" {
+		checks = append(checks, `required("server.jwtSecret", c.Server.JWTSecret)`)
+	}
+	if opts.TimeoutMiddleware {
+		checks = append(checks, `if c.Context.Timeout < 0 {
+		problems = append(problems, fmt.Sprintf("context.timeout must not be negative, got %s", c.Context.Timeout))
+	}`)
+	}
+	if usesDatabase() {
+		checks = append(checks,
+			`required("database.host", c.Database.Host)`,
+			`port("database.port", c.Database.Port)`,
+			`required("database.user", c.Database.User)`,
+			`required("database.dbname", c.Database.Dbname)`)
+	}
+	if opts.RateLimit {
+		checks = append(checks, `if c.RateLimit.RequestsPerSecond <= 0 || c.RateLimit.Burst < 1 {
+		problems = append(problems, fmt.Sprintf("rateLimit needs a positive requestsPerSecond and burst, got %g and %d", c.RateLimit.RequestsPerSecond, c.RateLimit.Burst))
+	}`)
+	}
+	if opts.Messaging == "nats" {
+		checks = append(checks, `required("messaging.url", c.Messaging.URL)`)
+	}
+	if opts.Cache == "redis" {
+		checks = append(checks, `required("cache.addr", c.Cache.Addr)`)
+	}
+	if opts.Pprof {
+		checks = append(checks, `if c.Pprof.Enabled {
+		port("pprof.port", c.Pprof.Port)
+	}`)
+	}
+	if opts.ConfigCommands {
+		checks = append(checks, `for i, cmd := range c.Commands {
+		if cmd.Name == "" || len(cmd.Run) == 0 {
+			problems = append(problems, fmt.Sprintf("commands[%d] needs a name and a run program", i))
+		}
+	}`)
+	}
+
+	body := strings.Join(checks, "\n\t")
+	required := ""
+	if strings.Contains(body, "required(") {
+		required = `
+	required := func(name, value string) {
+		if value == "" {
+			problems = append(problems, name+" is required")
+		}
+	}`
+	}
+
+	return fmt.Sprintf(`
+// Validate reports every missing or invalid field at once, so a
+// misconfigured service fails at startup instead of misbehaving later
+func (c *Config) Validate() error {
+	var problems []string%s
+	port := func(name string, value int) {
+		if value < 1 || value > 65535 {
+			problems = append(problems, fmt.Sprintf("%%s must be a port between 1 and 65535, got %%d", name, value))
+		}
+	}
+
+	%s
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+`, required, body)
+}
+
+// failOnInvalidConfig stops an entrypoint on a config that exists but does
+// not load, leaving the built-in defaults to a missing config file
+const failOnInvalidConfig = `
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("❌ %v", err)
+	}`
+
+// envOverlaySource is appended to shared/config/config.go with --env-prefix
+const envOverlaySource = `
+// envPrefix namespaces the environment variables overriding config values
+const envPrefix = %q
+
+// applyEnv overrides config fields from environment variables named after
+// their yaml path: server.port is read from <envPrefix>_SERVER_PORT
+func applyEnv(v reflect.Value, prefix string) error {
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + "_" + envName(name)
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			return fmt.Errorf("invalid %%s: %%w", key, err)
+		}
+	}
+	return nil
+}
+
+// envName turns a yaml key such as maxOpenConns into MAX_OPEN_CONNS
+func envName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %%s", field.Type())
+	}
+	return nil
+}
+`
+
+// envVar returns the environment variable overriding a yaml config path
+// such as "server.port", following the generated applyEnv naming
+func envVar(path string) string {
+	var b strings.Builder
+	b.WriteString(opts.EnvPrefix)
+	for _, part := range strings.Split(path, ".") {
+		b.WriteByte('_')
+		for i, r := range part {
+			if i > 0 && r >= 'A' && r <= 'Z' {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		}
+	}
+	return strings.ToUpper(b.String())
+}
+
+// envExample renders .env.example, listing the overrides most often set
+// locally. godotenv does not override variables that are already set.
+func envExample() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Copy to .env for local overrides of %s; the API loads it at startup.\n", configFile())
+	b.WriteString("# Every service reads the same names, so set per-service values in the environment.\n")
+	paths := []string{"server.port"}
+	if usesDatabase() {
+		paths = append(paths, "database.host", "database.port", "database.user", "database.password", "database.dbname")
+	}
+	for _, path := range paths {
+		fmt.Fprintf(&b, "# %s=\n", envVar(path))
+	}
+	if opts.Pprof {
+		// Profiling is off in config and only turned on for local runs
+		fmt.Fprintf(&b, "%s=true\n", envVar("pprof.enabled"))
+	}
+	return b.String()
+}
+
+// configYAML renders services/<service>/config/config.yaml
+func configYAML(project string, port int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server:\n  port: %d\n", port)
+	if opts.Transport == "grpc" {
+		fmt.Fprintf(&b, "  grpcPort: %d\n", port+1000)
+	}
+	if opts.Transport == "graphql" {
+		fmt.Fprintf(&b, "  graphqlPort: %d\n", port+1000)
+	}
+	if opts.Auth == "jwt" {
+		fmt.Fprintf(&b, "  jwtSecret: %s # HS256 signing secret, override in production\n", newSecret())
+	}
+	if opts.TimeoutMiddleware {
+		b.WriteString("context:\n  timeout: 5s # per-request deadline, 0 disables it\n")
+	}
+	if opts.RateLimit {
+		b.WriteString("rateLimit:\n  requestsPerSecond: 10\n  burst: 20\n")
+	}
+	if opts.Messaging == "nats" {
+		b.WriteString("messaging:\n  url: nats://localhost:4222\n")
+	}
+	if opts.Cache == "redis" {
+		b.WriteString("cache:\n  addr: localhost:6379\n  password: \"\"\n  db: 0\n")
+	}
+	if opts.Pprof {
+		fmt.Fprintf(&b, "pprof:\n  enabled: false # enable for local profiling only\n  port: %d\n", port+2000)
+	}
+	if opts.FeatureFlags {
+		fmt.Fprintf(&b, "features:\n  %s: false # serves /beta when true\n", betaFeature)
+	}
+	if opts.ConfigCommands {
+		fmt.Fprintf(&b, "commands: # extra CLI commands, arguments follow those of run\n  - name: %s\n    short: %s\n    run: [go, env]\n", sampleCommand.name, sampleCommand.short)
+	}
+	if usesDatabase() {
+		fmt.Fprintf(&b, "database:\n  host: localhost\n  port: 5432\n  user: postgres\n  password: postgres\n  dbname: %s\n  sslmode: disable\n  maxOpenConns: 10\n  maxIdleConns: 5\n", project)
+	}
+	return b.String()
+}
+
+// configInCode reports whether services compile their configuration in
+// (--config code) instead of reading config/config.yaml
+func configInCode() bool {
+	return opts.Config == "code"
+}
+
+// configLocation names the file holding a service's configuration
+func configLocation() string {
+	if configInCode() {
+		return "config/defaults.go"
+	}
+	return "config/" + configFile()
+}
+
+// loadConfigCall returns the expression loading a service's configuration
+// in its entrypoints and the imports it needs besides shared/config
+func loadConfigCall(project, service string) (string, []string) {
+	if configInCode() {
+		return "config.Load(serviceconfig.DefaultConfig())", []string{"serviceconfig " + serviceImport(project, service, "config")}
+	}
+	return fmt.Sprintf("config.LoadConfig(%q)", service), nil
+}
+
+// configDefaultsSource renders config/defaults.go, the --config code
+// counterpart of configYAML
+func configDefaultsSource(project, service string, port int) string {
+	var std []string
+	values := []string{fmt.Sprintf("c.Server.Port = %d", port)}
+	if opts.Transport == "grpc" {
+		values = append(values, fmt.Sprintf("c.Server.GRPCPort = %d", port+1000))
+	}
+	if opts.Transport == "graphql" {
+		values = append(values, fmt.Sprintf("c.Server.GraphQLPort = %d", port+1000))
+	}
+	if opts.Auth == "jwt" {
+		values = append(values, fmt.Sprintf("c.Server.JWTSecret = %q // HS256 signing secret, override in production", newSecret()))
+	}
+	if opts.TimeoutMiddleware {
+		std = append(std, "time")
+		values = append(values, "c.Context.Timeout = 5 * time.Second // per-request deadline, 0 disables it")
+	}
+	if opts.RateLimit {
+		values = append(values, "c.RateLimit.RequestsPerSecond = 10", "c.RateLimit.Burst = 20")
+	}
+	if opts.Messaging == "nats" {
+		values = append(values, `c.Messaging.URL = "nats://localhost:4222"`)
+	}
+	if opts.Cache == "redis" {
+		values = append(values, `c.Cache.Addr = "localhost:6379"`)
+	}
+	if opts.Pprof {
+		values = append(values, "c.Pprof.Enabled = false // enable for local profiling only", fmt.Sprintf("c.Pprof.Port = %d", port+2000))
+	}
+	if opts.FeatureFlags {
+		values = append(values, fmt.Sprintf("c.Features = map[string]bool{%q: false} // serves /beta when true", betaFeature))
+	}
+	if opts.ConfigCommands {
+		values = append(values, fmt.Sprintf("c.Commands = []sharedconfig.Command{{Name: %q, Short: %q, Run: []string{\"go\", \"env\"}}} // extra CLI commands", sampleCommand.name, sampleCommand.short))
+	}
+	if usesDatabase() {
+		values = append(values, `c.Database.Host = "localhost"`, "c.Database.Port = 5432",
+			`c.Database.User = "postgres"`, `c.Database.Password = "postgres"`,
+			fmt.Sprintf("c.Database.Dbname = %q", project), `c.Database.Sslmode = "disable"`,
+			"c.Database.MaxOpenConns = 10", "c.Database.MaxIdleConns = 5")
+	}
+
+	return goSource("config", std, []string{"sharedconfig " + project + "/shared/config"}, fmt.Sprintf(`// DefaultConfig returns the compiled-in configuration of the %s service.
+// config.Load applies %s_* environment variable overrides on top of it.
+func DefaultConfig() *sharedconfig.Config {
+	var c sharedconfig.Config
+	%s
+	return &c
+}
+`, service, opts.EnvPrefix, strings.Join(values, "\n\t")))
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// With --config-format protobuf the configuration schema is a protobuf
+// message in the shared/proto module, next to the gRPC contracts:
+//
+//	shared/proto/config/v1/config.proto  the Config message
+//	shared/proto/gen/config/v1           its Go code, from make proto
+//	services/<service>/config            config.txtpb, or a binary config.binpb
+//
+// shared/config unmarshals the message and converts it to the Config
+// struct the entrypoints already use, so only the loader changes.
+
+// configProtoDir holds config.proto, in the config.v1 package
+const configProtoDir = protoModuleDirName + "/config/v1"
+
+// protobufConfig reports whether services read a protobuf config file
+func protobufConfig() bool {
+	return opts.ConfigFormat == "protobuf"
+}
+
+// usesProtoModule reports whether the project has the shared/proto module
+func usesProtoModule() bool {
+	return opts.Transport == "grpc" || protobufConfig()
+}
+
+// configFile names the config file of a service in its config directory
+func configFile() string {
+	if protobufConfig() {
+		return "config.txtpb"
+	}
+	return "config.yaml"
+}
+
+// configProtoImport returns the import of the generated config package
+func configProtoImport(project string) string {
+	return "configv1 " + project + "/" + protoGenDir + "/config/v1"
+}
+
+// protoLoaderTpl reads a service's config/config.txtpb, or config.binpb;
+// %[2]s applies the environment overrides
+const protoLoaderTpl = `func LoadConfig(service string) (*Config, error) {
+	dir := "./%[1]s/" + service + "/config/"
+	var pb configv1.Config
+	data, err := os.ReadFile(dir + "config.txtpb")
+	if err == nil {
+		err = prototext.Unmarshal(data, &pb)
+	} else if errors.Is(err, fs.ErrNotExist) {
+		data, err = os.ReadFile(dir + "config.binpb")
+		if err == nil {
+			err = proto.Unmarshal(data, &pb)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := fromProto(&pb)
+	if err != nil {
+		return nil, err
+	}%[2]s
+	return &config, nil
+}
+
+// fromProto converts the loaded message into Config. Durations are strings
+// in the message, parsed with time.ParseDuration.
+func fromProto(pb *configv1.Config) (Config, error) {
+	var config Config
+	%[3]s
+	return config, nil
+}`
+
+// protoLoaderSource renders LoadConfig for --config-format protobuf, with
+// the imports it needs besides those of the YAML loader
+func protoLoaderSource(project, load string) (string, []string, []string) {
+	std := []string{"errors", "io/fs"}
+	mods := []string{"google.golang.org/protobuf/encoding/prototext", "google.golang.org/protobuf/proto", configProtoImport(project)}
+	duration := func(field, getter, name string) string {
+		return fmt.Sprintf(`if v := pb.%[2]s; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %[3]s: %%w", err)
+		}
+		config.%[1]s = d
+	}`, field, getter, name)
+	}
+
+	fields := []string{"config.Server.Port = int(pb.GetServer().GetPort())"}
+	if opts.Transport == "grpc" {
+		fields = append(fields, "config.Server.GRPCPort = int(pb.GetServer().GetGrpcPort())")
+	}
+	if opts.Transport == "graphql" {
+		fields = append(fields, "config.Server.GraphQLPort = int(pb.GetServer().GetGraphqlPort())")
+	}
+	if opts.Auth == "jwt" {
+		fields = append(fields, "config.Server.JWTSecret = pb.GetServer().GetJwtSecret()")
+	}
+	if usesDatabase() {
+		fields = append(fields,
+			"config.Database.Driver = pb.GetDatabase().GetDriver()",
+			"config.Database.Host = pb.GetDatabase().GetHost()",
+			"config.Database.Port = int(pb.GetDatabase().GetPort())",
+			"config.Database.User = pb.GetDatabase().GetUser()",
+			"config.Database.Password = pb.GetDatabase().GetPassword()",
+			"config.Database.Dbname = pb.GetDatabase().GetDbname()",
+			"config.Database.Sslmode = pb.GetDatabase().GetSslmode()",
+			"config.Database.MaxOpenConns = int(pb.GetDatabase().GetMaxOpenConns())",
+			"config.Database.MaxIdleConns = int(pb.GetDatabase().GetMaxIdleConns())",
+			duration("Database.ConnMaxLifetime", "GetDatabase().GetConnMaxLifetime()", "database.conn_max_lifetime"))
+	}
+	if opts.TimeoutMiddleware {
+		fields = append(fields, duration("Context.Timeout", "GetContext().GetTimeout()", "context.timeout"))
+	}
+	if opts.RateLimit {
+		fields = append(fields,
+			"config.RateLimit.RequestsPerSecond = pb.GetRateLimit().GetRequestsPerSecond()",
+			"config.RateLimit.Burst = int(pb.GetRateLimit().GetBurst())")
+	}
+	if opts.Messaging == "nats" {
+		fields = append(fields, "config.Messaging.URL = pb.GetMessaging().GetUrl()")
+	}
+	if opts.Cache == "redis" {
+		fields = append(fields,
+			"config.Cache.Addr = pb.GetCache().GetAddr()",
+			"config.Cache.Password = pb.GetCache().GetPassword()",
+			"config.Cache.DB = int(pb.GetCache().GetDb())")
+	}
+	if opts.Pprof {
+		fields = append(fields,
+			"config.Pprof.Enabled = pb.GetPprof().GetEnabled()",
+			"config.Pprof.Port = int(pb.GetPprof().GetPort())")
+	}
+	if opts.FeatureFlags {
+		fields = append(fields, "config.Features = pb.GetFeatures()")
+	}
+	if opts.ConfigCommands {
+		fields = append(fields, `for _, c := range pb.GetCommands() {
+		config.Commands = append(config.Commands, Command{Name: c.GetName(), Short: c.GetShort(), Run: c.GetRun()})
+	}`)
+	}
+	if usesDatabase() || opts.TimeoutMiddleware {
+		std = append(std, "fmt")
+	}
+	return fmt.Sprintf(protoLoaderTpl, servicesRel(), load, strings.Join(fields, "\n\t")), std, mods
+}
+
+// configProtoSource renders config.proto. Field numbers are fixed per
+// field, whichever features the project was generated with, so messages
+// stay compatible as features are added.
+func configProtoSource(project string) string {
+	var fields, messages []string
+	message := func(name, field string, number int, body ...string) {
+		fields = append(fields, fmt.Sprintf("%s %s = %d;", name, field, number))
+		messages = append(messages, fmt.Sprintf("message %s {\n  %s\n}", name, strings.Join(body, "\n  ")))
+	}
+
+	server := []string{"int32 port = 1;"}
+	if opts.Transport == "grpc" {
+		server = append(server, "int32 grpc_port = 2;")
+	}
+	if opts.Transport == "graphql" {
+		server = append(server, "int32 graphql_port = 3;")
+	}
+	if opts.Auth == "jwt" {
+		server = append(server, "string jwt_secret = 4;")
+	}
+	message("Server", "server", 1, server...)
+	if usesDatabase() {
+		message("Database", "database", 2,
+			"string driver = 1;", "string host = 2;", "int32 port = 3;", "string user = 4;",
+			"string password = 5;", "string dbname = 6;", "string sslmode = 7;",
+			"int32 max_open_conns = 8;", "int32 max_idle_conns = 9;",
+			"// A Go duration such as \"5m\"", "string conn_max_lifetime = 10;")
+	}
+	if opts.TimeoutMiddleware {
+		message("Context", "context", 3, "// A Go duration such as \"5s\", 0 disables the deadline", "string timeout = 1;")
+	}
+	if opts.RateLimit {
+		message("RateLimit", "rate_limit", 4, "double requests_per_second = 1;", "int32 burst = 2;")
+	}
+	if opts.Messaging == "nats" {
+		message("Messaging", "messaging", 5, "string url = 1;")
+	}
+	if opts.Cache == "redis" {
+		message("Cache", "cache", 6, "string addr = 1;", "string password = 2;", "int32 db = 3;")
+	}
+	if opts.Pprof {
+		message("Pprof", "pprof", 7, "bool enabled = 1;", "int32 port = 2;")
+	}
+	if opts.FeatureFlags {
+		fields = append(fields, "map<string, bool> features = 8;")
+	}
+	if opts.ConfigCommands {
+		fields = append(fields, "repeated Command commands = 9;")
+		messages = append(messages, "// A CLI command running the program and arguments of run\nmessage Command {\n  string name = 1;\n  string short = 2;\n  repeated string run = 3;\n}")
+	}
+
+	return fmt.Sprintf(`syntax = "proto3";
+
+// Configuration of a service, read by shared/config from
+// services/<service>/config/config.txtpb or its binary form config.binpb
+package config.v1;
+
+option go_package = "%s/%s/config/v1;configv1";
+
+message Config {
+  %s
+}
+
+%s
+`, project, protoGenDir, strings.Join(fields, "\n  "), strings.Join(messages, "\n\n"))
+}
+
+// configTextproto renders services/<service>/config/config.txtpb, the
+// --config-format protobuf counterpart of configYAML
+func configTextproto(project string, port int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# proto-file: %s/config.proto\n# proto-message: config.v1.Config\n\n", configProtoDir)
+	fmt.Fprintf(&b, "server {\n  port: %d\n", port)
+	if opts.Transport == "grpc" {
+		fmt.Fprintf(&b, "  grpc_port: %d\n", port+1000)
+	}
+	if opts.Transport == "graphql" {
+		fmt.Fprintf(&b, "  graphql_port: %d\n", port+1000)
+	}
+	if opts.Auth == "jwt" {
+		fmt.Fprintf(&b, "  jwt_secret: %q # HS256 signing secret, override in production\n", newSecret())
+	}
+	b.WriteString("}\n")
+	if opts.TimeoutMiddleware {
+		b.WriteString("context {\n  timeout: \"5s\" # per-request deadline, 0 disables it\n}\n")
+	}
+	if opts.RateLimit {
+		b.WriteString("rate_limit {\n  requests_per_second: 10\n  burst: 20\n}\n")
+	}
+	if opts.Messaging == "nats" {
+		b.WriteString("messaging {\n  url: \"nats://localhost:4222\"\n}\n")
+	}
+	if opts.Cache == "redis" {
+		b.WriteString("cache {\n  addr: \"localhost:6379\"\n  password: \"\"\n  db: 0\n}\n")
+	}
+	if opts.Pprof {
+		fmt.Fprintf(&b, "pprof {\n  enabled: false # enable for local profiling only\n  port: %d\n}\n", port+2000)
+	}
+	if opts.FeatureFlags {
+		fmt.Fprintf(&b, "features {\n  key: %q\n  value: false # serves /beta when true\n}\n", betaFeature)
+	}
+	if opts.ConfigCommands {
+		fmt.Fprintf(&b, "commands { # an extra CLI command, arguments follow those of run\n  name: %q\n  short: %q\n  run: \"go\"\n  run: \"env\"\n}\n", sampleCommand.name, sampleCommand.short)
+	}
+	if usesDatabase() {
+		fmt.Fprintf(&b, "database {\n  host: \"localhost\"\n  port: 5432\n  user: \"postgres\"\n  password: \"postgres\"\n  dbname: %q\n  sslmode: \"disable\"\n  max_open_conns: 10\n  max_idle_conns: 5\n}\n", project)
+	}
+	return b.String()
+}
+
+// createConfigProto writes config.proto into the shared/proto module,
+// generates its Go code and makes the shared module depend on it. It runs
+// before shared is tidied.
+func createConfigProto(project string) {
+	ensureProtoModule(project)
+	writeFile(filepath.Join(project, configProtoDir), "config.proto", configProtoSource(project))
+	if err := generateProto(project); err != nil {
+		warnf("Failed to generate the config protobuf code: %v", err)
+	} else {
+		fmt.Println("🧬 Config protobuf code generated in", protoGenDir+"/config/v1")
+	}
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, "\nconfig-binpb-%:") {
+		appendContent(makefilePath, fmt.Sprintf(`config-binpb-%%: ## Encode a service's config.txtpb to the binary config.binpb, e.g. make config-binpb-<service>
+	buf convert %[1]s --type config.v1.Config --from %[2]s/$*/config/config.txtpb --to %[2]s/$*/config/config.binpb
+
+`, protoModuleDirName, servicesRel()))
+	}
+
+	if opts.SingleModule {
+		return
+	}
+	goModTidy(filepath.Join(project, protoModuleDirName))
+	shared := filepath.Join(project, "shared")
+	if err := runCmd(shared, "go", "mod", "edit", "-require", project+"/shared/proto@v0.0.0", "-replace", project+"/shared/proto=./proto"); err != nil {
+		warnf("Failed to run 'go mod edit' in %s", shared)
+	}
+}
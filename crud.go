@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// createExampleCRUD writes the --example-crud reference implementation over
+// the example table of db/schema.sql: a repository over the db connection,
+// handlers with their routes and handler tests against an in-memory store
+func createExampleCRUD(project, service string) {
+	repository := serviceImport(project, service, "internal/repository")
+
+	writeFile(servicePackage(project, service, "internal/repository"), "example.go", goSource("repository",
+		[]string{"context", "database/sql", "errors"},
+		nil,
+		renderTemplate(`// Example is a row of the example table
+type Example struct {
+	ID   int64  §json:"id"§
+	Name string §json:"name"§
+}
+
+// ErrNotFound is returned when no example has the requested ID
+var ErrNotFound = errors.New("example not found")
+
+// ExampleRepository stores examples in the example table of db/schema.sql
+type ExampleRepository struct {
+	db *sql.DB
+}
+
+func NewExampleRepository(db *sql.DB) *ExampleRepository {
+	return &ExampleRepository{db: db}
+}
+
+func (r *ExampleRepository) Create(ctx context.Context, name string) (Example, error) {
+	e := Example{Name: name}
+	err := r.db.QueryRowContext(ctx, "INSERT INTO example (name) VALUES ($1) RETURNING id", name).Scan(&e.ID)
+	return e, err
+}
+
+func (r *ExampleRepository) Get(ctx context.Context, id int64) (Example, error) {
+	e := Example{ID: id}
+	err := r.db.QueryRowContext(ctx, "SELECT name FROM example WHERE id = $1", id).Scan(&e.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Example{}, ErrNotFound
+	}
+	return e, err
+}
+
+func (r *ExampleRepository) List(ctx context.Context) ([]Example, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name FROM example ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	examples := []Example{}
+	for rows.Next() {
+		var e Example
+		if err := rows.Scan(&e.ID, &e.Name); err != nil {
+			return nil, err
+		}
+		examples = append(examples, e)
+	}
+	return examples, rows.Err()
+}
+
+func (r *ExampleRepository) Update(ctx context.Context, id int64, name string) (Example, error) {
+	res, err := r.db.ExecContext(ctx, "UPDATE example SET name = $1 WHERE id = $2", name, id)
+	if err != nil {
+		return Example{}, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return Example{}, ErrNotFound
+	}
+	return Example{ID: id, Name: name}, nil
+}
+
+func (r *ExampleRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM example WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+`, '§')))
+
+	api := servicePackage(project, service, "api")
+	writeFile(api, "examples.go", goSource("api",
+		[]string{"context", "encoding/json", "errors", "net/http", "strconv", "strings"},
+		[]string{project + "/shared/apierror", repository},
+		renderTemplate(`// ExampleStore is the storage ExampleHandler needs, implemented by
+// repository.ExampleRepository
+type ExampleStore interface {
+	Create(ctx context.Context, name string) (repository.Example, error)
+	Get(ctx context.Context, id int64) (repository.Example, error)
+	List(ctx context.Context) ([]repository.Example, error)
+	Update(ctx context.Context, id int64, name string) (repository.Example, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// ExampleHandler serves create, read, update and delete routes for the
+// example table
+type ExampleHandler struct {
+	store ExampleStore
+}
+
+func NewExampleHandler(store ExampleStore) *ExampleHandler {
+	return &ExampleHandler{store: store}
+}
+
+// Routes registers the handlers on mux under /examples
+func (h *ExampleHandler) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /examples", h.Create)
+	mux.HandleFunc("GET /examples", h.List)
+	mux.HandleFunc("GET /examples/{id}", h.Get)
+	mux.HandleFunc("PUT /examples/{id}", h.Update)
+	mux.HandleFunc("DELETE /examples/{id}", h.Delete)
+}
+
+// ExampleRequest is the JSON body of Create and Update
+type ExampleRequest struct {
+	Name string §json:"name"§
+}
+
+func (h *ExampleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	name, err := decodeExample(w, r)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	example, err := h.store.Create(r.Context(), name)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, example)
+}
+
+func (h *ExampleHandler) List(w http.ResponseWriter, r *http.Request) {
+	examples, err := h.store.List(r.Context())
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, examples)
+}
+
+func (h *ExampleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := exampleID(r)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	example, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		apierror.WriteError(w, storeError(err))
+		return
+	}
+	respondJSON(w, http.StatusOK, example)
+}
+
+func (h *ExampleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := exampleID(r)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	name, err := decodeExample(w, r)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	example, err := h.store.Update(r.Context(), id, name)
+	if err != nil {
+		apierror.WriteError(w, storeError(err))
+		return
+	}
+	respondJSON(w, http.StatusOK, example)
+}
+
+func (h *ExampleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := exampleID(r)
+	if err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	if err := h.store.Delete(r.Context(), id); err != nil {
+		apierror.WriteError(w, storeError(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// exampleID parses the {id} path segment
+func exampleID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, apierror.New(apierror.InvalidRequest, "id must be a positive integer")
+	}
+	return id, nil
+}
+
+// decodeExample reads and validates an ExampleRequest
+func decodeExample(w http.ResponseWriter, r *http.Request) (string, error) {
+	var req ExampleRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return "", apierror.New(apierror.InvalidRequest, "invalid JSON body: "+err.Error())
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", apierror.New(apierror.ValidationError, "name is required")
+	}
+	return name, nil
+}
+
+// storeError maps repository.ErrNotFound to a 404; other errors are 500s
+func storeError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return apierror.New(apierror.NotFound, err.Error())
+	}
+	return err
+}
+
+func respondJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+`, '§')))
+
+	testMods, check := []string{repository}, `if rec.Code != step.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", step.name, rec.Code, step.want, rec.Body.String())
+		}`
+	if testify() {
+		testMods = append(testMods, "github.com/stretchr/testify/assert")
+		check = `assert.Equalf(t, step.want, rec.Code, "%s (body %s)", step.name, rec.Body.String())`
+	}
+	writeFile(api, "examples_test.go", goSource("api",
+		[]string{"context", "net/http", "net/http/httptest", "strings", "sync", "testing"},
+		testMods,
+		`// memoryStore is an in-memory ExampleStore, so the handlers are tested
+// without a database
+type memoryStore struct {
+	mu       sync.Mutex
+	nextID   int64
+	examples map[int64]repository.Example
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{examples: map[int64]repository.Example{}}
+}
+
+func (s *memoryStore) Create(ctx context.Context, name string) (repository.Example, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	e := repository.Example{ID: s.nextID, Name: name}
+	s.examples[e.ID] = e
+	return e, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, id int64) (repository.Example, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.examples[id]
+	if !ok {
+		return repository.Example{}, repository.ErrNotFound
+	}
+	return e, nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]repository.Example, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var examples []repository.Example
+	for id := int64(1); id <= s.nextID; id++ {
+		if e, ok := s.examples[id]; ok {
+			examples = append(examples, e)
+		}
+	}
+	return examples, nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, id int64, name string) (repository.Example, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.examples[id]; !ok {
+		return repository.Example{}, repository.ErrNotFound
+	}
+	e := repository.Example{ID: id, Name: name}
+	s.examples[id] = e
+	return e, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.examples[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.examples, id)
+	return nil
+}
+
+func TestExampleCRUD(t *testing.T) {
+	mux := http.NewServeMux()
+	NewExampleHandler(newMemoryStore()).Routes(mux)
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"create", http.MethodPost, "/examples", `+"`"+`{"name":"gopher"}`+"`"+`, http.StatusCreated},
+		{"create without name", http.MethodPost, "/examples", `+"`"+`{}`+"`"+`, http.StatusUnprocessableEntity},
+		{"create malformed", http.MethodPost, "/examples", `+"`"+`{`+"`"+`, http.StatusBadRequest},
+		{"get", http.MethodGet, "/examples/1", "", http.StatusOK},
+		{"get invalid id", http.MethodGet, "/examples/abc", "", http.StatusBadRequest},
+		{"list", http.MethodGet, "/examples", "", http.StatusOK},
+		{"update", http.MethodPut, "/examples/1", `+"`"+`{"name":"gordon"}`+"`"+`, http.StatusOK},
+		{"update missing", http.MethodPut, "/examples/2", `+"`"+`{"name":"gordon"}`+"`"+`, http.StatusNotFound},
+		{"delete", http.MethodDelete, "/examples/1", "", http.StatusNoContent},
+		{"get deleted", http.MethodGet, "/examples/1", "", http.StatusNotFound},
+	}
+	// The steps share the store, so they run in order
+	for _, step := range steps {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(step.method, step.path, strings.NewReader(step.body)))
+		`+check+`
+	}
+}
+`))
+
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Example CRUD") {
+		appendContent(readme, fmt.Sprintf(`
+## Example CRUD
+
+The API serves the example table of db/schema.sql, through
+internal/repository and api/examples.go:
+
+    POST   /examples        {"name": "..."}
+    GET    /examples
+    GET    /examples/{id}
+    PUT    /examples/{id}   {"name": "..."}
+    DELETE /examples/{id}
+
+The connection uses the database section of %s. Create the table
+before the first request:
+
+    psql -h localhost -U postgres -d %s -f %s
+`, configLocation(), project, filepath.ToSlash(filepath.Join(serviceRel(service), packageRel("db"), "schema.sql"))))
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// pgxDriver is the database/sql driver opened by db.Open
+const pgxDriver = "github.com/jackc/pgx/v5/stdlib"
+
+// usesDatabase reports whether services connect to the database of their
+// config, for --example-crud or --seed-data
+func usesDatabase() bool {
+	return opts.ExampleCRUD || opts.SeedData
+}
+
+// createDatabase writes db/db.go, opening the connection pool described by
+// the database section of the service config
+func createDatabase(project, service string) {
+	writeFile(servicePackage(project, service, "db"), "db.go", goSource("db",
+		[]string{"database/sql", "errors", "fmt", "net/url", "time"},
+		[]string{"_ " + pgxDriver, project + "/shared/config"},
+		`// Open returns a connection pool for the database section of cfg. It does
+// not connect: the first query does, so the service starts without the
+// database and requests fail until it is reachable.
+func Open(cfg *config.Config) (*sql.DB, error) {
+	if cfg == nil {
+		return nil, errors.New("no database configuration")
+	}
+	c := cfg.Database
+	driver := c.Driver
+	if driver == "" {
+		driver = "pgx"
+	}
+	sslmode := c.Sslmode
+	if sslmode == "" {
+		sslmode = "prefer"
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:     c.Dbname,
+		RawQuery: url.Values{"sslmode": {sslmode}}.Encode(),
+	}
+
+	db, err := sql.Open(driver, dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	} else {
+		db.SetConnMaxLifetime(30 * time.Minute)
+	}
+	return db, nil
+}
+`))
+}
+
+// createSeedData writes db/seed.sql with sample rows for the example table,
+// the db.Seed function embedding it, a CLI seed command applying it and
+// its make seed-<service> target
+func createSeedData(project, service string) {
+	dbDir := servicePackage(project, service, "db")
+	writeFile(dbDir, "seed.sql", `-- Sample rows for local development, applied by the CLI seed command.
+-- Rows already present are left alone, so seeding twice is harmless.
+INSERT INTO example (name)
+SELECT v.name FROM (VALUES ('Ada'), ('Grace'), ('Linus'), ('Rob')) AS v(name)
+WHERE NOT EXISTS (SELECT 1 FROM example e WHERE e.name = v.name);
+`)
+
+	writeFile(dbDir, "seed.go", goSource("db",
+		[]string{"context", "database/sql", "_ embed"},
+		nil,
+		`//go:embed seed.sql
+var seedSQL string
+
+// Seed inserts the sample rows of seed.sql. The example table must exist:
+// apply schema.sql first.
+func Seed(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, seedSQL)
+	return err
+}
+`))
+
+	load, loadMods := loadConfigCall(project, service)
+	writeFile(servicePackage(project, service, "cli"), "seed.go", goSource("cli",
+		[]string{"fmt"},
+		append([]string{"github.com/spf13/cobra", project + "/shared/config", serviceImport(project, service, "db")}, loadMods...),
+		fmt.Sprintf(`var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Insert the sample rows of db/seed.sql into the configured database",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := %s
+		if err != nil {
+			return err
+		}
+		database, err := db.Open(config)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		if err := db.Seed(cmd.Context(), database); err != nil {
+			return err
+		}
+		fmt.Println("🌱 Seeded the example table")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(seedCmd)
+}
+`, load)))
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, fmt.Sprintf("seed-%s:", service)) {
+		appendContent(makefilePath, fmt.Sprintf(`seed-%[1]s: ## Insert the %[1]s sample rows into its database
+	%[2]s seed
+
+`, service, goRunCmd(service, "cli")))
+	}
+
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Seed data") {
+		appendContent(readme, fmt.Sprintf(`
+## Seed data
+
+db/seed.sql holds sample rows for the example table. Create the table, then
+insert them into the database of %s:
+
+    psql -h localhost -U postgres -d %s -f %s
+    make seed-%s
+`, configLocation(), project, filepath.ToSlash(filepath.Join(serviceRel(service), packageRel("db"), "schema.sql")), service))
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// With --ddd (--arch ddd) the flat internal/service package is replaced by
+// a package per aggregate, named after the service:
+//
+//	internal/<aggregate>  the aggregate root, the repository interface
+//	                      persisting it and the domain service
+//	delivery/http         HTTP handlers depending on the domain service
+//
+// As with --arch clean, the entrypoints wire the service to a repository.
+
+// reservedAggregates are packages imported next to the aggregate, which
+// it cannot be named after
+var reservedAggregates = map[string]bool{
+	"api": true, "cli": true, "client": true, "config": true, "context": true, "db": true,
+	"entity": true, "errors": true, "events": true, "flags": true, "fmt": true, "graph": true,
+	"http": true, "httpdelivery": true, "log": true, "net": true, "os": true, "repository": true,
+	"shutdown": true, "strings": true, "sync": true, "testing": true, "time": true, "usecase": true,
+	"version": true,
+}
+
+// dddArch reports whether services use the --ddd aggregate packages
+func dddArch() bool {
+	return opts.Arch == "ddd"
+}
+
+// aggregate returns the package name of the sample aggregate of a
+// service: its name lowercased, without separators
+func aggregate(service string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, service)
+}
+
+// aggregateRoot returns the type name of the aggregate root
+func aggregateRoot(service string) string {
+	name := aggregate(service)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// checkAggregates validates the aggregate package names of the services
+func checkAggregates(services []string) {
+	if !dddArch() {
+		return
+	}
+	for _, service := range services {
+		name := aggregate(service)
+		if name == "" || !unicode.IsLetter(rune(name[0])) || token.IsKeyword(name) || reservedAggregates[name] {
+			log.Fatalf("❌ Service %q gives the aggregate package name %q, which --ddd cannot use; pick another service name.", service, name)
+		}
+	}
+}
+
+// createDDD writes the aggregate and delivery/http packages of a service
+func createDDD(project, service string) {
+	pkg, root := aggregate(service), aggregateRoot(service)
+	dir := servicePackage(project, service, "internal/"+pkg)
+
+	writeFile(dir, pkg+".go", goSource(pkg,
+		[]string{"errors", "strings", "time"},
+		nil,
+		fmt.Sprintf(`// ErrEmptyName is returned when a %[1]s is created without a name
+var ErrEmptyName = errors.New("name is required")
+
+// %[1]s is the aggregate root. Create it with New, which enforces its
+// invariants, and add methods for the changes the %[2]s domain allows.
+type %[1]s struct {
+	Name      string
+	Message   string
+	CreatedAt time.Time
+}
+
+// New creates a %[1]s greeted by name
+func New(name string) (%[1]s, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return %[1]s{}, ErrEmptyName
+	}
+	return %[1]s{Name: name, Message: "👋 Hello " + name, CreatedAt: time.Now()}, nil
+}
+`, root, pkg)))
+
+	writeFile(dir, "repository.go", goSource(pkg,
+		[]string{"context", "sync"},
+		nil,
+		fmt.Sprintf(`// Repository persists %[1]s aggregates. The domain declares it;
+// infrastructure provides implementations.
+type Repository interface {
+	Save(ctx context.Context, a %[1]s) error
+}
+
+// MemoryRepository keeps aggregates in memory. Replace it with a
+// database-backed Repository.
+type MemoryRepository struct {
+	mu    sync.Mutex
+	saved []%[1]s
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (r *MemoryRepository) Save(ctx context.Context, a %[1]s) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, a)
+	return nil
+}
+`, root)))
+
+	writeFile(dir, "service.go", goSource(pkg,
+		[]string{"context"},
+		nil,
+		fmt.Sprintf(`// Service carries out the %[2]s use cases on a Repository
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Greet creates a %[1]s for name and saves it
+func (s *Service) Greet(ctx context.Context, name string) (%[1]s, error) {
+	a, err := New(name)
+	if err != nil {
+		return %[1]s{}, err
+	}
+	if err := s.repo.Save(ctx, a); err != nil {
+		return %[1]s{}, err
+	}
+	return a, nil
+}
+`, root, pkg)))
+
+	createDelivery(project, service)
+
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Domain") {
+		appendContent(readme, fmt.Sprintf(`
+## Domain
+
+    %-21s the %s aggregate: root entity, repository interface, domain service
+    %-21s HTTP handlers, depending on the domain service
+
+Add a package per aggregate under internal/. Aggregates only change through
+their own methods, and cmd/ wires each domain service to a repository.
+`, packageRel("internal/"+pkg), pkg, "delivery/http"))
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// debugf writes a --debug trace line to stderr
+func debugf(format string, args ...any) {
+	if opts.Debug {
+		fmt.Fprintf(os.Stderr, "🐞 "+format+"\n", args...)
+	}
+}
+
+// debugOptions traces every flag with its resolved value
+func debugOptions() {
+	if !opts.Debug {
+		return
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		debugf("option --%s=%s", f.Name, f.Value)
+	})
+	debugf("option single-module=%t (after detection), go=%s (%s)", opts.SingleModule, goVer, goPatchVer)
+}
+
+// runTraced runs cmd, tracing its argv, directory and exit code
+func runTraced(cmd *exec.Cmd) error {
+	err := cmd.Run()
+	if opts.Debug {
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			code = -1
+		}
+		dir := cmd.Dir
+		if dir == "" {
+			dir = "."
+		}
+		debugf("exec [%s] in %s: exit %d", strings.Join(cmd.Args, " "), dir, code)
+	}
+	return err
+}
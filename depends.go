@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// serviceReplace matches the replace directive of a sibling service module,
+// as written for --depends-on, capturing the service directory
+var serviceReplace = regexp.MustCompile(`=>\s*\.\./([^/\s]+)\s*$`)
+
+// dependencies returns the services service depends on with --depends-on
+func dependencies(service string) []string {
+	var deps []string
+	for _, dep := range opts.DependsOn {
+		if dep != service {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// serviceGraph returns the dependencies of the project's existing services,
+// read from the replace directives of their go.mod, plus those the new
+// services get from --depends-on
+func serviceGraph(project string, services []string) map[string][]string {
+	graph := map[string][]string{}
+	for _, existing := range listServices(project) {
+		data, err := os.ReadFile(filepath.Join(serviceDir(project, existing), "go.mod"))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := serviceReplace.FindStringSubmatch(line); m != nil {
+				graph[existing] = append(graph[existing], m[1])
+			}
+		}
+	}
+	for _, service := range services {
+		graph[service] = dedup(append(graph[service], dependencies(service)...))
+	}
+	return graph
+}
+
+// orderServices checks --depends-on against the project and sorts services
+// so that each comes after the new services it depends on. It fails on an
+// unknown service or a dependency cycle.
+func orderServices(project string, services []string) ([]string, error) {
+	if len(opts.DependsOn) == 0 {
+		return services, nil
+	}
+	existing := listServices(project)
+	for _, dep := range opts.DependsOn {
+		if !slices.Contains(existing, dep) && !slices.Contains(services, dep) {
+			return nil, fmt.Errorf("unknown service %q in --depends-on", dep)
+		}
+	}
+
+	graph := serviceGraph(project, services)
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var ordered []string
+	var visit func(service string, path []string) error
+	visit = func(service string, path []string) error {
+		switch state[service] {
+		case visiting:
+			return fmt.Errorf("dependency cycle %s", strings.Join(append(path, service), " → "))
+		case done:
+			return nil
+		}
+		state[service] = visiting
+		deps := slices.Clone(graph[service])
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep, append(path, service)); err != nil {
+				return err
+			}
+		}
+		state[service] = done
+		if slices.Contains(services, service) {
+			ordered = append(ordered, service)
+		}
+		return nil
+	}
+	for _, service := range services {
+		if err := visit(service, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// dependencyClosure returns every service service depends on, directly or
+// through another service, sorted
+func dependencyClosure(project, service string) []string {
+	graph := serviceGraph(project, []string{service})
+	seen := map[string]bool{}
+	var walk func(s string)
+	walk = func(s string) {
+		for _, dep := range graph[s] {
+			if dep != service && !seen[dep] {
+				seen[dep] = true
+				walk(dep)
+			}
+		}
+	}
+	walk(service)
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// wireDependencies points the service module at the modules of the
+// services it depends on and lists them in its README. In the single-module
+// layout they are packages of the same module already.
+func wireDependencies(project, service string) {
+	deps := dependencies(service)
+	if len(deps) == 0 {
+		return
+	}
+	if !opts.SingleModule {
+		// Without go.work, as in the Docker build, only the replaces of the
+		// main module apply: indirect dependencies need one too
+		for _, dep := range dependencyClosure(project, service) {
+			replace := fmt.Sprintf("%s/%s=../%s", project, dep, dep)
+			if err := runCmd(serviceDir(project, service), "go", "mod", "edit", "-replace", replace); err != nil {
+				warnf("Failed to add the replace directive %s", replace)
+			}
+		}
+	}
+
+	imports := make([]string, len(deps))
+	for i, dep := range deps {
+		imports[i] = fmt.Sprintf("- %s (%s)", dep, serviceImport(project, dep, "..."))
+	}
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Dependencies") {
+		appendContent(readme, fmt.Sprintf(`
+## Dependencies
+
+This service imports packages of the following services, such as their
+client package, resolved from the local checkout:
+
+%s
+`, strings.Join(imports, "\n")))
+	}
+}
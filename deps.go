@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Dependency update configuration written with --deps, listing every
+// module explicitly since the project holds one go.mod per service
+const (
+	renovateConfig   = "renovate.json"
+	dependabotConfig = ".github/dependabot.yml"
+)
+
+// writeDepsConfig re-renders the renovate or dependabot configuration from
+// the modules currently on disk, whatever the overwrite policy. Projects
+// generated with --deps keep their choice when services are added later.
+func writeDepsConfig(project string) {
+	kind := opts.Deps
+	if kind == "" {
+		if _, err := os.Stat(filepath.Join(project, renovateConfig)); err == nil {
+			kind = "renovate"
+		} else if _, err := os.Stat(filepath.Join(project, dependabotConfig)); err == nil {
+			kind = "dependabot"
+		}
+	}
+
+	modules := goModuleDirs(project)
+	switch kind {
+	case "renovate":
+		paths := make([]string, len(modules))
+		for i, dir := range modules {
+			paths[i] = fmt.Sprintf("%q", path.Join(dir, "go.mod"))
+		}
+		rewriteFile(project, renovateConfig, fmt.Sprintf(`{
+  "$schema": "https://docs.renovatebot.com/renovate-schema.json",
+  "extends": ["config:recommended"],
+  "includePaths": [
+    %s
+  ],
+  "postUpdateOptions": ["gomodTidy", "gomodUpdateImportPaths"]
+}
+`, strings.Join(paths, ",\n    ")))
+	case "dependabot":
+		var dirs strings.Builder
+		for _, dir := range modules {
+			fmt.Fprintf(&dirs, "      - %q\n", path.Join("/", dir))
+		}
+		rewriteFile(project, dependabotConfig, fmt.Sprintf(`version: 2
+updates:
+  - package-ecosystem: gomod
+    directories:
+%s    schedule:
+      interval: weekly
+    groups:
+      go-modules:
+        patterns: ["*"]
+`, dirs.String()))
+	}
+}
+
+// goModuleDirs returns the directories holding a go.mod, relative to the
+// project root with forward slashes
+func goModuleDirs(project string) []string {
+	candidates := []string{".", "shared", protoModuleDirName}
+	for _, service := range listServices(project) {
+		candidates = append(candidates, filepath.ToSlash(serviceRel(service)))
+	}
+
+	var dirs []string
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(project, dir, "go.mod")); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// ANSI colors of the diff output, empty when colors are off
+var (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+	colorBold  = "\033[1m"
+	colorReset = "\033[0m"
+)
+
+func init() {
+	// Colors only on a terminal, and never with NO_COLOR set
+	info, err := os.Stdout.Stat()
+	if os.Getenv("NO_COLOR") != "" || err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		colorRed, colorGreen, colorCyan, colorBold, colorReset = "", "", "", "", ""
+	}
+}
+
+// unifiedDiff returns the changes from old to new as a colorized unified
+// diff of path, or "" when they are equal
+func unifiedDiff(path, old, new string) string {
+	if old == new {
+		return ""
+	}
+	a, b := splitLines(old), splitLines(new)
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s--- a/%s\n+++ b/%s%s\n", colorBold, path, path, colorReset)
+	for start := 0; start < len(ops); {
+		// Skip to the next change, keeping diffContext lines before it
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		start = max(start-diffContext, 0)
+
+		// Extend the hunk until more than 2*diffContext unchanged lines
+		// separate it from the next change
+		end, same := start, 0
+		for end < len(ops) && same <= 2*diffContext {
+			if ops[end].kind == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+			end++
+		}
+		end -= max(same-diffContext, 0)
+
+		oldLine, newLine, oldCount, newCount := ops[start].oldLine, ops[start].newLine, 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		// Ranges start at 1, or 0 when a side is empty
+		if oldCount > 0 {
+			oldLine++
+		}
+		if newCount > 0 {
+			newLine++
+		}
+		fmt.Fprintf(&out, "%s@@ -%d,%d +%d,%d @@%s\n", colorCyan, oldLine, oldCount, newLine, newCount, colorReset)
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case '-':
+				fmt.Fprintf(&out, "%s-%s%s\n", colorRed, op.text, colorReset)
+			case '+':
+				fmt.Fprintf(&out, "%s+%s%s\n", colorGreen, op.text, colorReset)
+			default:
+				fmt.Fprintf(&out, " %s\n", op.text)
+			}
+		}
+		start = end
+	}
+	return out.String()
+}
+
+// diffOp is a line of a diff: ' ' unchanged, '-' removed or '+' added, with
+// the number of lines of each side before it
+type diffOp struct {
+	kind             byte
+	text             string
+	oldLine, newLine int
+}
+
+// diffLines computes a line diff from the longest common subsequence. The
+// common prefix and suffix are trimmed first, so appends stay cheap.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the common subsequence of midA[i:] and midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	add := func(kind byte, text string) {
+		ops = append(ops, diffOp{kind: kind, text: text, oldLine: i, newLine: j})
+		switch kind {
+		case '-':
+			i++
+		case '+':
+			j++
+		default:
+			i, j = i+1, j+1
+		}
+	}
+	for _, line := range a[:prefix] {
+		add(' ', line)
+	}
+	for x, y := 0, 0; x < len(midA) || y < len(midB); {
+		switch {
+		case x < len(midA) && y < len(midB) && midA[x] == midB[y]:
+			add(' ', midA[x])
+			x, y = x+1, y+1
+		case x < len(midA) && (y == len(midB) || lcs[x+1][y] >= lcs[x][y+1]):
+			add('-', midA[x])
+			x++
+		default:
+			add('+', midB[y])
+			y++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		add(' ', line)
+	}
+	return ops
+}
+
+// splitLines splits s into lines without their line breaks
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// snapshotFiles reads the project files a new service may change, leaving
+// out the services themselves, build output, git data and go.sum files
+func snapshotFiles(project string) map[string]string {
+	skip := map[string]bool{".git": true, "bin": true, "vendor": true, filepath.Clean(servicesRel()): true}
+	files := map[string]string{}
+	filepath.WalkDir(project, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(project, path)
+		if d.IsDir() {
+			if skip[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.sum" || !d.Type().IsRegular() {
+			return nil
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			files[rel] = string(data)
+		}
+		return nil
+	})
+	return files
+}
+
+// stdinIsTerminal reports whether prompts can be answered: scripts and CI
+// jobs pipe stdin, close it or point it at /dev/null, a character device too
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// reviewChanges shows a diff of the project files that differ between the
+// before and after snapshots and asks to apply them. Without a terminal to
+// answer on, they are applied.
+func reviewChanges(before, after map[string]string) bool {
+	var paths []string
+	for path, content := range after {
+		if old, ok := before[path]; !ok || old != content {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return true
+	}
+	sort.Strings(paths)
+
+	fmt.Println("\nChanges to the project files:")
+	for _, path := range paths {
+		fmt.Print(unifiedDiff(filepath.ToSlash(path), before[path], after[path]))
+	}
+	if !opts.Yes && !stdinIsTerminal() {
+		fmt.Println("ℹ️  Applied the changes: stdin is not a terminal to confirm them on.")
+		return true
+	}
+	return confirm(fmt.Sprintf("The new services change %d project files as shown.", len(paths)))
+}
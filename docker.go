@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// imageName returns the image repository of a service, without registry
+func imageName(project, service string) string {
+	return project + "-" + service
+}
+
+// registryPrefix returns the --registry value ready to prefix an image name
+func registryPrefix() string {
+	if opts.Registry == "" {
+		return ""
+	}
+	return strings.TrimSuffix(opts.Registry, "/") + "/"
+}
+
+// cliBuild returns the build command appended for the healthcheck CLI
+// with --health
+func cliBuild(pkg string) string {
+	if !opts.Health {
+		return ""
+	}
+	return " && \\\n    go build -o /out/cli " + pkg
+}
+
+// createDocker writes a multi-stage Dockerfile for the service and its
+// docker-build/docker-push Makefile targets. Module files are copied before
+// the sources so the dependency download layer survives code changes.
+func createDocker(project, service string, port int) {
+	ldflags := fmt.Sprintf(`-X %[1]s/shared/version.Version=${VERSION} -X %[1]s/shared/version.Commit=${COMMIT}`, project)
+	rel := serviceRel(service)
+	protoModFiles := ""
+	if usesProtoModule() {
+		protoModFiles = fmt.Sprintf("COPY %[1]s/go.* ./%[1]s/\n", protoModuleDirName)
+	}
+
+	// Services of --depends-on are replaced with their local checkout
+	var depModFiles, depSources string
+	for _, dep := range dependencyClosure(project, service) {
+		depRel := filepath.ToSlash(serviceRel(dep))
+		depModFiles += fmt.Sprintf("COPY %[1]s/go.* ./%[1]s/\n", depRel)
+		depSources += fmt.Sprintf("COPY %[1]s ./%[1]s\n", depRel)
+	}
+
+	build := fmt.Sprintf(`ENV GOWORK=off CGO_ENABLED=0
+
+# Module files first so the download layer is cached between builds; go.*
+# as a module without external imports, such as shared, has no go.sum
+COPY shared/go.* ./shared/
+%[4]s%[5]sCOPY %[1]s/go.* ./%[1]s/
+RUN --mount=type=cache,target=/go/pkg/mod cd %[1]s && go mod download
+
+COPY shared ./shared
+%[6]sCOPY %[1]s ./%[1]s
+ARG VERSION=dev
+ARG COMMIT=none
+RUN --mount=type=cache,target=/go/pkg/mod --mount=type=cache,target=/root/.cache/go-build \
+    cd %[1]s && go build -ldflags "%[2]s" -o /out/api ./cmd/api%[3]s`, rel, ldflags, cliBuild("./cmd/cli"), protoModFiles, depModFiles, depSources)
+	if opts.SingleModule {
+		build = fmt.Sprintf(`ENV CGO_ENABLED=0
+
+# Module files first so the download layer is cached between builds
+COPY go.mod go.sum ./
+RUN --mount=type=cache,target=/go/pkg/mod go mod download
+
+COPY . .
+ARG VERSION=dev
+ARG COMMIT=none
+RUN --mount=type=cache,target=/go/pkg/mod --mount=type=cache,target=/root/.cache/go-build \
+    go build -ldflags "%[2]s" -o /out/api ./cmd/%[1]sapi%[3]s`, service, ldflags, cliBuild("./cmd/"+service+"cli"))
+	}
+
+	// Compiled-in config has no file to ship
+	configCopy := fmt.Sprintf("COPY %[1]s/config /app/%[1]s/config\n", filepath.ToSlash(rel))
+	if configInCode() {
+		configCopy = ""
+	}
+
+	// The distroless image has no shell or curl: the CLI checks /healthz
+	healthcheck := ""
+	if opts.Health {
+		healthcheck = `COPY --from=build /out/cli /app/cli
+HEALTHCHECK --interval=30s --timeout=5s --retries=3 CMD ["/app/cli", "healthcheck"]
+`
+	}
+
+	writeFile(serviceDir(project, service), "Dockerfile", fmt.Sprintf(`# syntax=docker/dockerfile:1
+# Build from the project root: docker build -f %[1]s/Dockerfile .
+FROM golang:%[2]s AS build
+WORKDIR /src
+%[3]s
+
+FROM gcr.io/distroless/static-debian12
+WORKDIR /app
+COPY --from=build /out/api /app/api
+%[6]s%[5]sEXPOSE %[4]d
+USER nonroot:nonroot
+ENTRYPOINT ["/app/api"]
+`, filepath.ToSlash(rel), goVer, build, port, configCopy, healthcheck))
+
+	if _, err := os.Stat(filepath.Join(project, ".dockerignore")); os.IsNotExist(err) {
+		writeFile(project, ".dockerignore", `.git
+bin/
+dist/
+*.log
+.env
+.env.*
+`)
+	}
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, "REGISTRY ?=") {
+		appendContent(makefilePath, fmt.Sprintf("REGISTRY ?= %s\n\n", registryPrefix()))
+	}
+	if !fileContainsText(makefilePath, fmt.Sprintf("docker-build-%s:", service)) {
+		image := "$(REGISTRY)" + imageName(project, service) + ":$(VERSION)"
+		appendContent(makefilePath, fmt.Sprintf(`docker-build-%[1]s: ## Build the %[1]s image
+	docker build -f %[2]s/Dockerfile --build-arg VERSION=$(VERSION) --build-arg COMMIT=$(COMMIT) -t %[3]s .
+
+docker-push-%[1]s: docker-build-%[1]s ## Build and push the %[1]s image
+	docker push %[3]s
+
+`, service, filepath.ToSlash(rel), image))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+// flagsSource is shared/flags/flags.go, generated with --feature-flags: the
+// feature toggles services check before enabling new code paths
+var flagsSource = formatGo(`// Package flags turns features on and off without a deploy. Services depend
+// on the Checker interface, so the config-driven Static checker can later be
+// replaced by a provider such as OpenFeature.
+package flags
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Checker reports whether the feature named key is enabled. The context
+// carries what a provider may target on, such as the request's user.
+type Checker interface {
+	IsEnabled(ctx context.Context, key string) bool
+}
+
+// Static is a Checker reading the features section of the service config.
+// FEATURE_<KEY>, e.g. FEATURE_BETA_GREETING for beta-greeting, overrides a
+// value with true or false. Unknown features are disabled.
+type Static map[string]bool
+
+// IsEnabled implements Checker
+func (s Static) IsEnabled(_ context.Context, key string) bool {
+	if raw, ok := os.LookupEnv(EnvName(key)); ok {
+		if enabled, err := strconv.ParseBool(raw); err == nil {
+			return enabled
+		}
+	}
+	return s[key]
+}
+
+// EnvName returns the environment variable overriding the feature key
+func EnvName(key string) string {
+	return "FEATURE_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+`)
+
+// betaFeature is the sample flag gating the API's /beta route
+const betaFeature = "beta-greeting"
+
+// betaHandlerSource renders api/beta.go, the sample handler gated by a
+// feature flag
+func betaHandlerSource(project string) string {
+	return goSource("api", []string{"fmt", "net/http"}, []string{project + "/shared/flags"}, `// BetaHandler answers /beta while the `+betaFeature+` feature is enabled and
+// 404 otherwise, so the route can be rolled out from config
+func BetaHandler(features flags.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !features.IsEnabled(r.Context(), "`+betaFeature+`") {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "🧪 Hello from the beta greeting!")
+	}
+}
+`)
+}
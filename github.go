@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// codeownersFile maps directories to their reviewers, written with
+// --github-meta and extended as services are added
+const codeownersFile = ".github/CODEOWNERS"
+
+// createGitHubMeta writes CODEOWNERS with the git author as default owner,
+// the pull request template and the issue templates
+func createGitHubMeta(project string) {
+	owner := defaultOwner()
+	writeFile(filepath.Join(project, ".github"), "CODEOWNERS", fmt.Sprintf(`# Reviewers requested for changes, see
+# https://docs.github.com/articles/about-code-owners
+# The last matching pattern wins: replace the owner of a directory with the
+# team maintaining it.
+* %[1]s
+
+/shared/ %[1]s
+`, owner))
+
+	writeFile(filepath.Join(project, ".github"), "pull_request_template.md", `## What
+
+<!-- What does this change and why? Link the issue it closes. -->
+
+Closes #
+
+## How to test
+
+<!-- Commands or requests showing the change works. -->
+
+## Checklist
+
+- [ ] The affected services build and their tests pass
+- [ ] Configuration changes are reflected in every config.yaml
+- [ ] Breaking API changes are called out above
+`)
+
+	issues := filepath.Join(project, ".github", "ISSUE_TEMPLATE")
+	writeFile(issues, "bug_report.md", `---
+name: Bug report
+about: Something does not work as expected
+labels: bug
+---
+
+## Service
+
+<!-- Which service and entrypoint (api, cli...) is affected? -->
+
+## What happened
+
+## What was expected
+
+## How to reproduce
+
+1.
+
+## Version
+
+<!-- The output of GET /version or cli version -->
+`)
+	writeFile(issues, "feature_request.md", `---
+name: Feature request
+about: Suggest a change or an addition
+labels: enhancement
+---
+
+## Problem
+
+<!-- What is hard or impossible today? -->
+
+## Proposal
+
+## Alternatives considered
+`)
+}
+
+// defaultOwner returns the git author email, which GitHub accepts as a
+// code owner when it belongs to a user with access to the repository
+func defaultOwner() string {
+	out, err := exec.Command("git", "config", "user.email").Output()
+	if email := strings.TrimSpace(string(out)); err == nil && email != "" {
+		return email
+	}
+	warnf("No git user.email to use as code owner; replace @owner in %s", codeownersFile)
+	return "@owner"
+}
+
+// addCodeOwner gives the service directory to the default owner of the
+// project's CODEOWNERS, when it has one
+func addCodeOwner(project, service string) {
+	path := filepath.Join(project, codeownersFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	dir := "/" + filepath.ToSlash(serviceRel(service)) + "/"
+	owner := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "*" {
+			owner = strings.Join(fields[1:], " ")
+		}
+		if strings.HasPrefix(line, dir+" ") {
+			return
+		}
+	}
+	if owner == "" {
+		warnf("No default owner (a * line) in %s; %s was not added", path, dir)
+		return
+	}
+	appendContent(path, dir+" "+owner+"\n")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	goversion "go/version"
+	"log"
+	"regexp"
+)
+
+// majorMinor matches the go directive versions --go-directive and
+// --workspace-go accept
+var majorMinor = regexp.MustCompile(`^1\.\d+$`)
+
+// modGoVersion returns the go directive of the generated go.mod files: the
+// minimum language version their code may use
+func modGoVersion() string {
+	if opts.GoDirective != "" {
+		return opts.GoDirective
+	}
+	return goVer
+}
+
+// workGoVersion returns the go directive of the generated go.work
+func workGoVersion() string {
+	if opts.WorkspaceGo != "" {
+		return opts.WorkspaceGo
+	}
+	return goVer
+}
+
+// minGoDirective returns the oldest go directive the generated code builds
+// with, and what needs it
+func minGoDirective() (version, reason string) {
+	if opts.Tests {
+		return "1.24", "the tests of --tests use t.Chdir, b.Loop and b.Context"
+	}
+	return "1.21", "shared/pagination uses the min builtin"
+}
+
+// checkGoVersions validates --go-directive and --workspace-go. The go
+// command rejects a workspace older than one of its modules.
+func checkGoVersions() {
+	for _, f := range []struct{ name, value string }{{"go-directive", opts.GoDirective}, {"workspace-go", opts.WorkspaceGo}} {
+		if f.value != "" && !majorMinor.MatchString(f.value) {
+			log.Fatalf("❌ Invalid --%s %q, expected a major.minor version such as 1.22.", f.name, f.value)
+		}
+	}
+	if oldest, reason := minGoDirective(); opts.GoDirective != "" && goversion.Compare("go"+opts.GoDirective, "go"+oldest) < 0 {
+		log.Fatalf("❌ --go-directive %s is too old, expected %s or later: %s.", opts.GoDirective, oldest, reason)
+	}
+	if goversion.Compare("go"+workGoVersion(), "go"+modGoVersion()) < 0 {
+		log.Fatalf("❌ The go.work version %s is older than the go.mod version %s; the go command would reject the workspace.", workGoVersion(), modGoVersion())
+	}
+	for _, v := range []string{modGoVersion(), workGoVersion()} {
+		if goversion.Compare("go"+v, "go"+goVer) > 0 {
+			warnf("Go %s is newer than the installed Go %s: the go command will download a matching toolchain", v, goVer)
+			break
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestMinGoDirective generates a project with --tests and the oldest go
+// directive accepted for it, and vets every module with that language version
+func TestMinGoDirective(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a project and downloads its dependencies")
+	}
+	opts.Tests = true
+	t.Cleanup(func() { opts.Tests = false })
+	directive, _ := minGoDirective()
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "create-go-project")
+	if out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	generate := exec.Command(bin, "demo", "--yes", "--service", "example", "--tests", "--go-directive", directive)
+	generate.Dir = dir
+	if out, err := generate.CombinedOutput(); err != nil {
+		t.Fatalf("generating with --go-directive %s: %v\n%s", directive, err, out)
+	}
+
+	project := filepath.Join(dir, "demo")
+	err := filepath.WalkDir(project, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.Name() != "go.mod" {
+			return err
+		}
+		vet := exec.Command("go", "vet", "./...")
+		vet.Dir = filepath.Dir(path)
+		vet.Env = append(os.Environ(), "GOWORK=off")
+		if out, err := vet.CombinedOutput(); err != nil {
+			t.Errorf("go vet in %s: %v\n%s", vet.Dir, err, out)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
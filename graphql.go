@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// gqlgenModule is the pinned gqlgen release recorded as a tool dependency
+const gqlgenModule = "github.com/99designs/gqlgen@v0.17.95"
+
+// createGraphQL adds a gqlgen schema, configuration and resolvers to the
+// service, generates the executable schema and wires a GraphQL server with
+// the playground into the service
+func createGraphQL(project, service string) {
+	servicePath := serviceDir(project, service)
+	graphDir := servicePackage(project, service, "graph")
+
+	writeFile(graphDir, "schema.graphqls", `type Query {
+  "Greets name, or says hello from the service when it is omitted"
+  hello(name: String): String!
+}
+`)
+
+	writeFile(servicePath, "gqlgen.yml", fmt.Sprintf(`# gqlgen configuration, see https://gqlgen.com/config/
+schema:
+  - %[1]s/*.graphqls
+
+exec:
+  filename: %[1]s/generated.go
+  package: graph
+
+model:
+  filename: %[1]s/model/models_gen.go
+  package: model
+
+resolver:
+  layout: follow-schema
+  dir: %[1]s
+  package: graph
+  filename_template: "{name}.resolvers.go"
+`, packageRel("graph")))
+
+	// The clean and DDD layers inject the greeter; the flat layout calls the service package
+	fields, resolverStd, resolverMods := "", []string(nil), []string(nil)
+	hello := "return service.Greet(ctx, *name)"
+	resolversMods := []string{serviceImport(project, service, "internal/service")}
+	newResolver := "&graph.Resolver{}"
+	mainMods := []string{
+		"github.com/99designs/gqlgen/graphql/handler",
+		"github.com/99designs/gqlgen/graphql/handler/extension",
+		"github.com/99designs/gqlgen/graphql/handler/transport",
+		"github.com/99designs/gqlgen/graphql/playground",
+		project + "/shared/config",
+		serviceImport(project, service, "graph"),
+	}
+	load, loadMods := loadConfigCall(project, service)
+	mainStd := []string{"fmt", "log", "net/http"}
+	if opts.ConfigValidate {
+		mainStd = append(mainStd, "errors", "io/fs")
+		load += failOnInvalidConfig
+	}
+	mainMods = append(mainMods, loadMods...)
+	if layeredArch() {
+		greeting, greetingMod := greetingType(project, service)
+		resolverStd = []string{"context"}
+		fields = "\n\tGreeter interface {\n\t\tGreet(ctx context.Context, name string) (" + greeting + ", error)\n\t}\n"
+		resolverMods = []string{greetingMod}
+		hello = "greeting, err := r.Greeter.Greet(ctx, *name)\n\tif err != nil {\n\t\treturn \"\", err\n\t}\n\treturn greeting.Message, nil"
+		resolversMods = nil
+		newResolver = "&graph.Resolver{Greeter: " + newGreeter(service) + "}"
+		mainMods = append(mainMods, greeterImports(project, service)...)
+	}
+
+	writeFile(graphDir, "resolver.go", goSource("graph", resolverStd, resolverMods, fmt.Sprintf(`//go:generate go tool gqlgen generate
+
+// Resolver is the root resolver; add the dependencies resolvers need here
+type Resolver struct {%s}
+`, fields)))
+
+	// gqlgen regenerates the resolver stubs next to the schema, so generate
+	// first and then fill in the sample query. Only resolvers that existed
+	// before this run are subject to the overwrite policy.
+	write := rewriteFile
+	if _, err := os.Stat(filepath.Join(graphDir, "schema.resolvers.go")); err == nil {
+		write = writeFile
+	}
+	if opts.SkipTidy {
+		fmt.Printf("⏭️  Skipped gqlgen generate. Run it before building:\n   (cd %s && go get -tool %s && go tool gqlgen generate)\n", servicePath, gqlgenModule)
+	} else if err := generateGraphQL(servicePath); err != nil {
+		warnf("Failed to generate the GraphQL schema: %v", err)
+	} else {
+		fmt.Println("🧬 GraphQL schema generated in", filepath.Join(servicePath, packageRel("graph")))
+	}
+
+	write(graphDir, "schema.resolvers.go", goSource("graph", []string{"context"}, resolversMods, fmt.Sprintf(`// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+// Hello is the resolver for the hello field.
+func (r *queryResolver) Hello(ctx context.Context, name *string) (string, error) {
+	if name == nil || *name == "" {
+		return "👋 Hello from the %s GraphQL API!", nil
+	}
+	%s
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type queryResolver struct{ *Resolver }
+`, service, hello)))
+
+	graphqlMainDir := cmdDir(project, service, "graphql")
+	if err := os.MkdirAll(graphqlMainDir, 0755); err != nil {
+		log.Fatalf("Error creating directory %s: %v", graphqlMainDir, err)
+	}
+	writeFile(graphqlMainDir, "main.go", goSource("main",
+		mainStd,
+		mainMods,
+		fmt.Sprintf(`func main() {
+	port := 9081
+	config, err := %s
+	if err == nil && config.Server.GraphQLPort != 0 {
+		port = config.Server.GraphQLPort
+	}
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: %s}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+
+	mux := http.NewServeMux()
+	mux.Handle("/", playground.Handler("%s GraphQL", "/query"))
+	mux.Handle("/query", srv)
+
+	log.Printf("🔌 GraphQL server running at :%%d (playground at /)\n", port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%%d", port), mux))
+}
+`, load, newResolver, service)))
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, "graphql:") {
+		appendContent(makefilePath, `graphql: ## Regenerate the GraphQL code from the schemas
+	@for cfg in services/*/gqlgen.yml internal/*/gqlgen.yml; do \
+		[ -f "$$cfg" ] || continue; \
+		echo "gqlgen generate in $$(dirname $$cfg)"; \
+		(cd $$(dirname $$cfg) && go tool gqlgen generate) || exit 1; \
+	done
+
+`)
+	}
+	if !fileContainsText(makefilePath, fmt.Sprintf("run-%s-graphql", service)) {
+		appendContent(makefilePath, fmt.Sprintf(`run-%[1]s-graphql: ## Run the %[1]s GraphQL server
+	%[2]s
+
+`, service, goRunCmd(service, "graphql")))
+	}
+}
+
+// generateGraphQL records gqlgen as a tool of the module containing dir and
+// runs it there. The new service is not in go.work yet, so the workspace is
+// turned off.
+func generateGraphQL(dir string) error {
+	if err := runGoOutsideWorkspace(os.Stdout, dir, "get", "-tool", gqlgenModule); err != nil {
+		return err
+	}
+	return runGoOutsideWorkspace(os.Stdout, dir, "tool", "gqlgen", "generate")
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Pinned code generators used by buf.gen.yaml and the buf fallback
+const (
+	bufModule          = "github.com/bufbuild/buf/cmd/buf@v1.50.0"
+	protocGenGo        = "google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.12"
+	protocGenGoGRPC    = "google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.6.2"
+	protoGenDir        = "shared/proto/gen"
+	protoModuleDirName = "shared/proto"
+)
+
+// createGRPC adds the service's protobuf contract to the shared/proto module,
+// generates the Go stubs and wires a gRPC server into the service
+func createGRPC(project, service string) {
+	ensureProtoModule(project)
+	if opts.GRPCGateway {
+		ensureGateway(project)
+	}
+
+	pkg := protoPackage(service)
+	name := exportedName(service)
+	protoDir := filepath.Join(project, protoModuleDirName, pkg, "v1")
+	if err := os.MkdirAll(protoDir, 0755); err != nil {
+		log.Fatalf("Error creating directory %s: %v", protoDir, err)
+	}
+
+	// With the gateway, methods declare their REST mapping
+	imports, greetRPC := "", "rpc Greet(GreetRequest) returns (GreetResponse);"
+	if opts.GRPCGateway {
+		imports = "\nimport \"google/api/annotations.proto\";\n"
+		greetRPC = `rpc Greet(GreetRequest) returns (GreetResponse) {
+    option (google.api.http) = {
+      post: "/v1/greet"
+      body: "*"
+    };
+  }`
+	}
+
+	writeFile(protoDir, pkg+".proto", fmt.Sprintf(`syntax = "proto3";
+
+package %s.v1;
+%s
+option go_package = "%s/%s/%s/v1;%sv1";
+
+service %sService {
+  %s
+}
+
+message GreetRequest {
+  string name = 1;
+}
+
+message GreetResponse {
+  string greeting = 1;
+}
+`, pkg, imports, project, protoGenDir, pkg, pkg, name, greetRPC))
+
+	// Generate stubs, then let the proto module pick up grpc and protobuf
+	if err := generateProto(project); err != nil {
+		warnf("Failed to generate protobuf stubs: %v", err)
+	} else {
+		fmt.Println("🧬 Protobuf stubs generated in", protoGenDir)
+	}
+	if !opts.SingleModule {
+		goModTidy(filepath.Join(project, protoModuleDirName))
+
+		servicePath := serviceDir(project, service)
+		if err := runCmd(servicePath, "go", "mod", "edit", "-replace", project+"/shared/proto=../../shared/proto"); err != nil {
+			warnf("Failed to run 'go mod edit'")
+		}
+	}
+
+	alias := pkg + "v1"
+	gen := fmt.Sprintf("%s %s/%s/%s/v1", alias, project, protoGenDir, pkg)
+
+	// The clean and DDD layers inject the greeter; the flat layout calls the service package
+	server, call, register := "", "service.Greet(ctx, req.GetName())", "api.GRPCServer{}"
+	serverMods := []string{gen, serviceImport(project, service, "internal/service")}
+	load, loadMods := loadConfigCall(project, service)
+	mainStd := []string{"fmt", "log", "net"}
+	if opts.ConfigValidate {
+		mainStd = append(mainStd, "errors", "io/fs")
+		load += failOnInvalidConfig
+	}
+	mainMods := append([]string{"google.golang.org/grpc", gen, project + "/shared/config", serviceImport(project, service, "api")}, loadMods...)
+	if layeredArch() {
+		greeting, greetingMod := greetingType(project, service)
+		server = "\n\tGreeter interface {\n\t\tGreet(ctx context.Context, name string) (" + greeting + ", error)\n\t}"
+		call = "s.Greeter.Greet(ctx, req.GetName())"
+		register = "api.GRPCServer{Greeter: " + newGreeter(service) + "}"
+		serverMods = []string{gen, greetingMod}
+		mainMods = append(mainMods, greeterImports(project, service)...)
+	}
+	greeting := "greeting"
+	if layeredArch() {
+		greeting = "greeting.Message"
+	}
+	method := fmt.Sprintf("greeting, err := %s\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn &%s.GreetResponse{Greeting: %s}, nil", call, alias, greeting)
+
+	writeFile(servicePackage(project, service, "api"), "grpc.go", goSource("api",
+		[]string{"context"},
+		serverMods,
+		fmt.Sprintf(`// GRPCServer implements %[1]s.%[2]sServiceServer
+type GRPCServer struct {
+	%[1]s.Unimplemented%[2]sServiceServer%[3]s
+}
+
+func (s GRPCServer) Greet(ctx context.Context, req *%[1]s.GreetRequest) (*%[1]s.GreetResponse, error) {
+	%[4]s
+}
+`, alias, name, server, method)))
+
+	grpcMainDir := cmdDir(project, service, "grpc")
+	if err := os.MkdirAll(grpcMainDir, 0755); err != nil {
+		log.Fatalf("Error creating directory %s: %v", grpcMainDir, err)
+	}
+	writeFile(grpcMainDir, "main.go", goSource("main",
+		mainStd,
+		mainMods,
+		fmt.Sprintf(`func main() {
+	port := 9081
+	config, err := %s
+	if err == nil && config.Server.GRPCPort != 0 {
+		port = config.Server.GRPCPort
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%%d", port))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	%s.Register%sServiceServer(srv, %s)
+	log.Printf("🔌 gRPC server running at :%%d\n", port)
+	log.Fatal(srv.Serve(lis))
+}
+`, load, alias, name, register)))
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, fmt.Sprintf("run-%s-grpc", service)) {
+		appendContent(makefilePath, fmt.Sprintf(`run-%[1]s-grpc: ## Run the %[1]s gRPC server
+	%[2]s
+
+`, service, goRunCmd(service, "grpc")))
+	}
+}
+
+// ensureProtoModule creates the shared/proto module and the root buf
+// configuration the first time a gRPC service is added
+func ensureProtoModule(project string) {
+	if _, err := os.Stat(filepath.Join(project, "buf.yaml")); err == nil {
+		return
+	}
+	protoPath := filepath.Join(project, protoModuleDirName)
+	if err := os.MkdirAll(filepath.Join(project, protoGenDir), 0755); err != nil {
+		log.Fatalf("Error creating directory %s: %v", protoPath, err)
+	}
+
+	// In the single-module layout the stubs are plain packages of the root module
+	if !opts.SingleModule {
+		writeFile(protoPath, "go.mod", fmt.Sprintf(`module %s/shared/proto
+
+go %s
+`, project, modGoVersion()))
+	}
+
+	writeFile(project, "buf.yaml", bufYAML(opts.GRPCGateway))
+	writeFile(project, "buf.gen.yaml", bufGenYAML(opts.GRPCGateway))
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, "proto:") {
+		appendContent(makefilePath, `proto: ## Regenerate the protobuf stubs
+	buf generate
+
+`)
+	}
+
+	readmePath := filepath.Join(project, "README.md")
+	if !fileContainsText(readmePath, "- shared/proto") {
+		appendContent(readmePath, "- shared/proto (protobuf contracts, stubs in shared/proto/gen)\n")
+	}
+}
+
+// bufYAML renders buf.yaml; with the gateway the vendored google.api
+// annotations are not linted
+func bufYAML(gateway bool) string {
+	ignore := ""
+	if gateway {
+		ignore = fmt.Sprintf("  ignore:\n    - %s\n", googleAPIDir)
+	}
+	return fmt.Sprintf(`version: v2
+modules:
+  - path: %s
+lint:
+  use:
+    - STANDARD
+%sbreaking:
+  use:
+    - FILE
+`, protoModuleDirName, ignore)
+}
+
+// bufGenYAML renders buf.gen.yaml; with the gateway it also runs
+// protoc-gen-grpc-gateway and skips the vendored google.api annotations
+func bufGenYAML(gateway bool) string {
+	inputs, plugins := "", []string{protocGenGo, protocGenGoGRPC}
+	if gateway {
+		inputs = fmt.Sprintf("inputs:\n  - directory: %s\n    exclude_paths:\n      - %s\n", protoModuleDirName, googleAPIDir)
+		plugins = append(plugins, protocGenGateway)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "version: v2\n%splugins:\n", inputs)
+	for _, plugin := range plugins {
+		fmt.Fprintf(&b, "  - local: [\"go\", \"run\", \"%s\"]\n    out: %s\n    opt: paths=source_relative\n", plugin, protoGenDir)
+	}
+	return b.String()
+}
+
+// generateProto runs buf generate from the project root, falling back to
+// go run when buf is not installed
+func generateProto(project string) error {
+	if _, err := exec.LookPath("buf"); err == nil {
+		return runCmd(project, "buf", "generate")
+	}
+	return runCmd(project, "go", "run", bufModule, "generate")
+}
+
+// protoPackage turns a service name into a valid protobuf package segment
+func protoPackage(service string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, service)
+}
+
+// exportedName turns a service name such as "billing-api" into "BillingApi"
+func exportedName(service string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(service, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
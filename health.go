@@ -0,0 +1,64 @@
+package main
+
+import "fmt"
+
+// healthSource renders api/health.go, the /healthz liveness handler
+// generated with --health
+const healthSource = `package api
+
+import "net/http"
+
+// HealthHandler answers 200 while the process serves requests. Unlike
+// /readyz it checks no dependency, so a restart cannot fix what it reports.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"status\":\"ok\"}\n"))
+}
+`
+
+// healthcheckCmdSource renders cli/healthcheck.go, calling the API's
+// /healthz so the CLI binary can serve as a container HEALTHCHECK
+func healthcheckCmdSource(project, service string) string {
+	load, loadMods := loadConfigCall(project, service)
+	return goSource("cli",
+		[]string{"fmt", "net/http", "time"},
+		append([]string{"github.com/spf13/cobra", project + "/shared/config"}, loadMods...),
+		fmt.Sprintf(`var healthcheckURL string
+
+var healthcheckCmd = &cobra.Command{
+	Use:   "healthcheck",
+	Short: "Exit 0 when the API answers /healthz, 1 otherwise",
+	Args:  cobra.NoArgs,
+	// Execute reports the error once, without the usage
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url := healthcheckURL
+		if url == "" {
+			port := 8081
+			if config, err := %[1]s; err == nil {
+				port = config.Server.Port
+			}
+			url = fmt.Sprintf("http://localhost:%%d/healthz", port)
+		}
+
+		client := http.Client{Timeout: 3 * time.Second}
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%%s answered %%s", url, resp.Status)
+		}
+		fmt.Println("✅ Healthy")
+		return nil
+	},
+}
+
+func init() {
+	healthcheckCmd.Flags().StringVar(&healthcheckURL, "url", "", "Health endpoint (default http://localhost:<server.port>/healthz)")
+	rootCmd.AddCommand(healthcheckCmd)
+}
+`, load))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// chartDir returns the directory of the --helm chart
+func chartDir(project string) string {
+	return filepath.Join(project, "deploy", "charts", filepath.Base(project))
+}
+
+// helmDeployment is the chart's Deployment template, one per entry of
+// .Values.services
+const helmDeployment = `{{- range $name, $svc := .Values.services }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ $.Release.Name }}-{{ $name }}
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+spec:
+  replicas: {{ $svc.replicas | default 1 }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ $name }}
+      app.kubernetes.io/instance: {{ $.Release.Name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ $name }}
+        app.kubernetes.io/instance: {{ $.Release.Name }}
+    spec:
+      containers:
+        - name: api
+          image: "{{ with $.Values.registry }}{{ . }}/{{ end }}{{ $svc.image }}:{{ $.Values.imageTag | default $.Chart.AppVersion }}"
+          ports:
+            - name: http
+              containerPort: {{ $svc.port }}%s
+{{- end }}
+`
+
+// helmService is the chart's Service template, one per entry of
+// .Values.services
+const helmService = `{{- range $name, $svc := .Values.services }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ $.Release.Name }}-{{ $name }}
+  labels:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+spec:
+  selector:
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ $.Release.Name }}
+  ports:
+    - name: http
+      port: {{ $svc.port }}
+      targetPort: http
+{{- end }}
+`
+
+// createHelmChart adds the service to the chart generated with --helm,
+// creating the chart on first use. The templates range over the services
+// of values.yaml, the last top-level block so new ones can be appended.
+func createHelmChart(project, service string, port int) {
+	dir := chartDir(project)
+	valuesPath := filepath.Join(dir, "values.yaml")
+	if _, err := os.Stat(valuesPath); os.IsNotExist(err) {
+		name := filepath.Base(project)
+		writeFile(dir, "Chart.yaml", fmt.Sprintf(`apiVersion: v2
+name: %s
+description: The services of %s
+type: application
+version: 0.1.0
+appVersion: "0.1.0"
+`, name, project))
+
+		// The distroless image has no shell: the probe asks the API itself
+		probe := ""
+		if opts.Health {
+			probe = `
+          readinessProbe:
+            httpGet:
+              path: /healthz
+              port: http`
+		}
+		writeFile(filepath.Join(dir, "templates"), "deployment.yaml", fmt.Sprintf(helmDeployment, probe))
+		writeFile(filepath.Join(dir, "templates"), "service.yaml", helmService)
+
+		writeFile(dir, "values.yaml", fmt.Sprintf(`# Registry prefixed to the images, as tagged by make docker-build-<service>
+registry: %q
+# Tag of every image, the chart appVersion when empty; make helm-upgrade
+# sets it to VERSION
+imageTag: ""
+
+# One Deployment and Service per entry, listening on port
+services:
+`, strings.TrimSuffix(opts.Registry, "/")))
+
+		makefilePath := filepath.Join(project, "Makefile")
+		if !fileContainsText(makefilePath, "\nhelm-upgrade:") {
+			appendContent(makefilePath, fmt.Sprintf(`helm-upgrade: ## Install or upgrade the Helm chart with the images of VERSION
+	helm upgrade --install %[1]s %[2]s --set imageTag=$(VERSION)
+
+`, name, filepath.ToSlash(filepath.Join("deploy", "charts", name))))
+		}
+
+		readmePath := filepath.Join(project, "README.md")
+		if !fileContainsText(readmePath, "- deploy/charts/") {
+			appendContent(readmePath, fmt.Sprintf("- deploy/charts/%s (Helm chart, a Deployment and Service per service)\n", name))
+		}
+	}
+
+	if fileContainsText(valuesPath, fmt.Sprintf("\n  %s:\n", service)) {
+		return
+	}
+	appendContent(valuesPath, fmt.Sprintf(`  %s:
+    image: %s
+    port: %d
+    replicas: 1
+`, service, imageName(project, service), port))
+}
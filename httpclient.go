@@ -0,0 +1,149 @@
+package main
+
+// httpclientSource is shared/httpclient/httpclient.go, generated with
+// --httpclient: the client services use for outbound calls
+var httpclientSource = formatGo(`// Package httpclient is an HTTP client for calls to third-party APIs. Unlike
+// http.DefaultClient it always has a timeout; it also retries network errors
+// and 5xx answers with exponential backoff, and can rate limit requests.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Config tunes a Client. Zero durations take the DefaultConfig values.
+type Config struct {
+	// Timeout bounds each attempt, including reading the response body
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled for each
+	// following one up to MaxBackoff, with jitter
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// RateLimit caps requests per second, allowing bursts of Burst
+	// requests; 0 disables it
+	RateLimit float64
+	Burst     int
+	// Transport defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// DefaultConfig is a 10s timeout and 3 retries from 100ms up to 2s apart,
+// without rate limiting
+func DefaultConfig() Config {
+	return Config{
+		Timeout:    10 * time.Second,
+		MaxRetries: 3,
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: 2 * time.Second,
+	}
+}
+
+// Client sends requests according to its Config. It is safe for
+// concurrent use; share one per remote API.
+type Client struct {
+	cfg     Config
+	http    *http.Client
+	limiter *rate.Limiter
+}
+
+func New(cfg Config) *Client {
+	defaults := DefaultConfig()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaults.Backoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+	c := &Client{cfg: cfg, http: &http.Client{Timeout: cfg.Timeout, Transport: cfg.Transport}}
+	if cfg.RateLimit > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), max(cfg.Burst, 1))
+	}
+	return c
+}
+
+// Do sends req, retrying network errors and 5xx answers. Only requests
+// safe to repeat are retried: GET, HEAD, OPTIONS, PUT and DELETE, or any
+// method with an Idempotency-Key header, and only when the body can be
+// replayed (http.NewRequest bodies can). The last response or error is
+// returned once the retries are exhausted.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := c.http.Do(req)
+		if attempt == c.cfg.MaxRetries || !retryable(req, resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body so the connection is reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// Get is a Do shorthand for a GET request
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// retryable reports whether the outcome of req is worth another attempt
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return err != nil || resp.StatusCode >= 500
+}
+
+// backoff returns the delay before retry attempt+1: Backoff doubled per
+// attempt, capped at MaxBackoff, with up to 50% jitter so clients that
+// failed together do not retry together
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.cfg.MaxBackoff
+	if attempt < 30 {
+		d = min(c.cfg.Backoff<<attempt, c.cfg.MaxBackoff)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+`)
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// By default every service is its own module under services/<service>, tied
+// together by go.work, or only by replace directives with --go-work-off.
+// With --workspace-root the services join an existing go.work above the
+// project instead of one of its own.
+// With --single-module the whole project is one module
+// and services become packages:
+//
+//	services/<service>/api                   internal/<service>/api
+//	services/<service>/internal/service      internal/<service>/service
+//	services/<service>/cmd/api               cmd/<service>api
+
+// servicesRel returns the directory holding all services, relative to the
+// project root
+func servicesRel() string {
+	if opts.SingleModule {
+		return "internal"
+	}
+	return "services"
+}
+
+// serviceRel returns the service directory relative to the project root
+func serviceRel(service string) string {
+	return filepath.Join(servicesRel(), service)
+}
+
+// serviceDir returns the directory holding the service's packages
+func serviceDir(project, service string) string {
+	return filepath.Join(project, serviceRel(service))
+}
+
+// servicePackage returns the directory of a service package such as "api"
+// or "internal/service"
+func servicePackage(project, service, pkg string) string {
+	return filepath.Join(serviceDir(project, service), packageRel(pkg))
+}
+
+// serviceImport returns the import path of a service package
+func serviceImport(project, service, pkg string) string {
+	if opts.SingleModule {
+		return project + "/internal/" + service + "/" + packageRel(pkg)
+	}
+	return project + "/" + service + "/" + pkg
+}
+
+// packageRel drops the nested internal/ segment in the single-module layout,
+// where the service already lives under internal/
+func packageRel(pkg string) string {
+	if opts.SingleModule {
+		return strings.TrimPrefix(pkg, "internal/")
+	}
+	return pkg
+}
+
+// moduleDir returns the directory of the module that contains the service
+func moduleDir(project, service string) string {
+	if opts.SingleModule {
+		return project
+	}
+	return serviceDir(project, service)
+}
+
+// cmdDir returns the directory of a service entrypoint (api, cli, grpc)
+func cmdDir(project, service, kind string) string {
+	if opts.SingleModule {
+		return filepath.Join(project, "cmd", service+kind)
+	}
+	return filepath.Join(project, "services", service, "cmd", kind)
+}
+
+// cmdPath returns an entrypoint path relative to the project root, as used
+// by go run and go build in the Makefile and Procfile
+func cmdPath(service, kind string) string {
+	if opts.SingleModule {
+		return "./cmd/" + service + kind
+	}
+	return "services/" + service + "/cmd/" + kind + "/main.go"
+}
+
+// goWorkOff reports whether service modules are wired to shared with
+// replace directives only (--go-work-off), without a go.work
+func goWorkOff() bool {
+	return opts.GoWorkOff && !opts.SingleModule
+}
+
+// workspaceDir returns the directory of the go.work listing the services:
+// the project root, or the --workspace-root directory relative to it
+func workspaceDir(project string) string {
+	if opts.WorkspaceRoot != "" {
+		return filepath.Join(project, opts.WorkspaceRoot)
+	}
+	return project
+}
+
+// parentWorkspace returns the directory of the go.work outside the project
+// that go resolves from it, relative to the project, or "" when none does
+func parentWorkspace(project string) string {
+	var out bytes.Buffer
+	if err := runCmdTo(&out, os.Stderr, project, "go", "env", "GOWORK"); err != nil {
+		return ""
+	}
+	gowork := strings.TrimSpace(out.String())
+	if gowork == "" || gowork == "off" {
+		return ""
+	}
+	rel, err := filepath.Rel(absPath(project), filepath.Dir(gowork))
+	if err != nil || !strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return rel
+}
+
+// absPath returns path as an absolute path, or unchanged if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// goBuildCmd returns the shell command building an entrypoint into
+// bin/<service>-<kind> from the project root. Without go.work the root is
+// not a module, so the build runs inside the service module with go -C.
+func goBuildCmd(service, kind, ldflags string) string {
+	out := "bin/" + service + "-" + kind
+	if goWorkOff() {
+		return fmt.Sprintf(`go build -C services/%s%s -ldflags "%s" -o ../../%s ./cmd/%s`, service, modFlag(), ldflags, out, kind)
+	}
+	return fmt.Sprintf(`go build%s -ldflags "%s" -o %s %s`, modFlag(), ldflags, out, cmdPath(service, kind))
+}
+
+// goRunCmd returns the shell command running an entrypoint from the project
+// root. Without go.work it builds the binary and starts it from the root,
+// where the relative config paths resolve.
+func goRunCmd(service, kind string) string {
+	if goWorkOff() {
+		bin := "bin/" + service + "-" + kind
+		return fmt.Sprintf("go build -C services/%s%s -o ../../%s ./cmd/%s && ./%s", service, modFlag(), bin, kind, bin)
+	}
+	return "go run" + modFlag() + " " + cmdPath(service, kind)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// createLoadTest writes loadtest/<service>.js, a k6 script hitting the
+// API's /hello route, and its make loadtest-<service> target
+func createLoadTest(project, service string, port int) {
+	// k6 opens files relative to the script, in its init stage
+	target := fmt.Sprintf("return `http://localhost:%d`;", port)
+	if !configInCode() {
+		config := filepath.ToSlash(filepath.Join("..", serviceRel(service), packageRel("config"), configFile()))
+		target = fmt.Sprintf(`const config = open('%s');
+  const port = config.match(/^server(?::| \{)\s*\n(?:[ \t].*\n)*?[ \t]+port:\s*(\d+)/m);
+  return §http://localhost:${port ? port[1] : %d}§;`, config, port)
+	}
+
+	writeFile(filepath.Join(project, "loadtest"), service+".js", renderTemplate(fmt.Sprintf(`// k6 load test of the %[1]s API: k6 run loadtest/%[1]s.js
+// Start the API first. BASE_URL, VUS and DURATION override the defaults.
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+
+export const options = {
+  vus: Number(__ENV.VUS || 10),
+  duration: __ENV.DURATION || '30s',
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    http_req_duration: ['p(95)<200'],
+  },
+};
+
+// The API address: BASE_URL, else the server.port the API listens on
+function baseURL() {
+  if (__ENV.BASE_URL) {
+    return __ENV.BASE_URL;
+  }
+  %[2]s
+}
+
+const BASE_URL = baseURL();
+
+export default function () {
+  const res = http.get(§${BASE_URL}%[3]s/hello?name=k6§);
+  check(res, { 'status is 200': (r) => r.status === 200 });
+  sleep(1);
+}
+`, service, target, routePrefix()), '§'))
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, fmt.Sprintf("loadtest-%s:", service)) {
+		appendContent(makefilePath, fmt.Sprintf(`loadtest-%[1]s: ## Load test the running %[1]s API with k6
+	k6 run loadtest/%[1]s.js
+
+`, service))
+	}
+}
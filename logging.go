@@ -0,0 +1,47 @@
+package main
+
+// traceHandlerSource is shared/logging/trace.go, generated with --otel-logs:
+// a slog.Handler stamping records with the OpenTelemetry span of their
+// context, so logs and traces of a request can be joined
+var traceHandlerSource = formatGo(`// Package logging correlates slog records with OpenTelemetry traces.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceHandler adds trace_id and span_id attributes to records logged with a
+// context carrying a span, such as the request context once an
+// instrumentation like otelhttp started one. Other records pass unchanged,
+// so it is harmless while tracing is off. Only the *Context logging
+// functions (slog.InfoContext...) hand the context to handlers.
+type TraceHandler struct {
+	slog.Handler
+}
+
+// NewTraceHandler wraps next
+func NewTraceHandler(next slog.Handler) *TraceHandler {
+	return &TraceHandler{Handler: next}
+}
+
+func (h *TraceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *TraceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *TraceHandler) WithGroup(name string) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithGroup(name)}
+}
+`)
@@ -3,19 +3,199 @@ package main
 
 import (
 	"bufio"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
 	"log"
-	"math/rand"
+	"math/rand/v2"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
-var goVer = getGoVersion()
+// goVer is the major.minor Go version used in go directives; goPatchVer is
+// the full version, e.g. 1.24.3, for toolchain pins
+var goVer, goPatchVer = getGoVersion()
+
+// options holds the generator settings resolved from flags
+type options struct {
+	StructuredLogging bool
+	GoEnv             envList
+	Rollback          bool
+	Transport         string
+	Procfile          bool
+	BasePort          int
+	Sqlc              bool
+	SingleModule      bool
+	Auth              string
+	RateLimit         bool
+	Runner            string
+	ReleaseTooling    bool
+	CI                string
+	Nix               bool
+	SkipTidy          bool
+	Seed              uint64
+	Docker            bool
+	Registry          string
+	Pprof             bool
+	Validation        bool
+	LayoutFile        string
+	PrintTree         bool
+	Systemd           bool
+	EnvPrefix         string
+	Tests             bool
+	Arch              string
+	Type              string
+	Messaging         string
+	Cache             string
+	Compose           bool
+	OS                string
+	Debug             bool
+	TimeoutMiddleware bool
+	Description       string
+	Require           moduleList
+	Strict            bool
+	Config            string
+	Yes               bool
+	Client            bool
+	GoWorkOff         bool
+	Deps              string
+	ExampleCRUD       bool
+	WorkspaceRoot     string
+	ToolVersions      bool
+	GRPCGateway       bool
+	Health            bool
+	OverwritePolicy   string
+	SeedData          bool
+	CheckModule       bool
+	TestFramework     string
+	OTelLogs          bool
+	NoReadme          bool
+	NoMakefile        bool
+	HTTPClient        bool
+	Wizard            bool
+	GitHubMeta        bool
+	DependsOn         serviceList
+	LoadTest          string
+	ConfigValidate    bool
+	Profile           string
+	GoDirective       string
+	WorkspaceGo       string
+	ResetPorts        bool
+	FeatureFlags      bool
+	ValidateTemplates bool
+	Vendor            bool
+	APIVersion        string
+	ShowConfig        bool
+	Helm              bool
+	Atomic            bool
+	DDD               bool
+	Realtime          string
+	TraceIDHeader     string
+	CIMatrix          string
+	ConfigFormat      string
+	Toolchain         string
+	ConfigCommands    bool
+}
+
+// envList is a repeatable KEY=VALUE flag
+type envList []string
+
+func (e *envList) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envList) Set(value string) error {
+	if key, _, ok := strings.Cut(value, "="); !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+// moduleList is a repeatable module[@version] flag
+type moduleList []string
+
+func (m *moduleList) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *moduleList) Set(value string) error {
+	if path, _, _ := strings.Cut(value, "@"); path == "" || strings.ContainsAny(value, " \t") {
+		return fmt.Errorf("expected module[@version], got %q", value)
+	}
+	*m = append(*m, value)
+	return nil
+}
+
+// serviceList is a repeatable, comma-separated list of service names
+type serviceList []string
+
+func (s *serviceList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *serviceList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*s = append(*s, name)
+		}
+	}
+	*s = dedup(*s)
+	return nil
+}
+
+var opts options
+
+// rollbackPaths lists directories created by this run, removed on --rollback
+var rollbackPaths []string
+
+// rng drives every randomized value in the generated output, such as
+// default secrets. --seed makes it, and so the whole scaffold, reproducible.
+var rng *rand.Rand
+
+// seedGiven records an explicit --seed: only then do secrets come from rng
+var seedGiven bool
+
+// newSecret returns a random 128-bit secret in hex. Without --seed it comes
+// from crypto/rand, as the fixed seed of --yes would give every project the
+// same secret.
+func newSecret() string {
+	if seedGiven {
+		return fmt.Sprintf("%016x%016x", rng.Uint64(), rng.Uint64())
+	}
+	var b [16]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		log.Fatalf("❌ Failed to generate a secret: %v", err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// skippedTidy lists modules whose go mod tidy was deferred by --skip-tidy
+var skippedTidy []string
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "self-update" {
+		selfUpdate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "list-templates" {
+		listTemplates(os.Args[2:])
+		return
+	}
+
 	// Handle project name (from arguments, not flags)
 	projectName := ""
 	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
@@ -25,15 +205,203 @@ func main() {
 		os.Args = newArgs
 	}
 
+	// Handle subcommands following the project name
+	command, bumpVersion := "", ""
+	if len(os.Args) > 1 && (os.Args[1] == "update" || os.Args[1] == "sync" || os.Args[1] == "bump-go") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+	// bump-go takes the Go version before its flags
+	if command == "bump-go" && len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		bumpVersion = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	// Define flags for service and skipPrompt options
-	serviceName := flag.String("service", "", "Service to scaffold")
-	skipPrompt := flag.Bool("yes", false, "Skip prompts and use defaults")
+	serviceName := flag.String("service", "", "Service to scaffold, or a comma-separated list")
+	flag.BoolVar(&opts.Yes, "yes", false, "Skip prompts and confirmations, using defaults")
+	flag.Var(&opts.GoEnv, "go-env", "Extra KEY=VALUE environment for go commands (repeatable)")
+	flag.BoolVar(&opts.RateLimit, "ratelimit", false, "Add token-bucket rate limiting (golang.org/x/time/rate) to the API")
+	flag.StringVar(&opts.Auth, "auth", "", "API authentication: jwt (default none)")
+	flag.StringVar(&opts.Transport, "transport", "http", "Service transport: http, grpc or graphql")
+	flag.IntVar(&opts.BasePort, "base-port", 8080, "Port of the first service; the Nth service gets base+N")
+	flag.BoolVar(&opts.SingleModule, "single-module", false, "Generate one go.mod for the whole project instead of a module per service")
+	flag.BoolVar(&opts.Sqlc, "sqlc", false, "Generate sqlc.yaml, db/queries.sql and a make sqlc target")
+	flag.StringVar(&opts.Runner, "runner", "make", "Task runner: make, or just to also generate a justfile")
+	flag.StringVar(&opts.CI, "ci", "", "CI pipeline to generate: gitlab (default none)")
+	flag.BoolVar(&opts.Nix, "nix", false, "Generate a flake.nix pinning the detected Go version")
+	flag.BoolVar(&opts.ReleaseTooling, "release-tooling", false, "Generate CHANGELOG.md and a make release VERSION=x.y.z target")
+	flag.BoolVar(&opts.Docker, "docker", false, "Generate a multi-stage Dockerfile and docker-build/docker-push targets per service")
+	flag.StringVar(&opts.Registry, "registry", "", "Container registry images are tagged for, e.g. ghcr.io/acme")
+	flag.BoolVar(&opts.Pprof, "pprof", false, "Serve net/http/pprof on a separate localhost port when enabled in config")
+	flag.BoolVar(&opts.Validation, "validation", false, "Add a shared/validate package checking request structs against go-playground/validator struct tags, used by the sample POST /greet")
+	flag.StringVar(&opts.LayoutFile, "layout-file", "", "YAML manifest of directories and file templates to generate")
+	flag.BoolVar(&opts.PrintTree, "print-tree", false, "Print the generated project as a tree diagram")
+	flag.BoolVar(&opts.Systemd, "systemd", false, "Generate deploy/<service>.service systemd units")
+	flag.StringVar(&opts.EnvPrefix, "env-prefix", "", "Let <PREFIX>_SERVER_PORT style environment variables override config")
+	flag.BoolVar(&opts.Tests, "tests", false, "Generate handler tests and benchmarks with make test and make bench targets")
+	flag.StringVar(&opts.Arch, "arch", "flat", "Service internals: flat, clean for entity/usecase/repository/delivery layers, or ddd for a package per aggregate")
+	flag.StringVar(&opts.Type, "type", "api", "Service type: api, or worker to also generate a queue-consuming cmd/worker; service=worker pairs, comma-separated, type the services named")
+	flag.StringVar(&opts.Messaging, "messaging", "", "Messaging integration: nats (default none)")
+	flag.StringVar(&opts.Cache, "cache", "", "Cache client: redis (default none)")
+	flag.BoolVar(&opts.Compose, "compose", false, "Generate docker-compose.yml with postgres and the services (implies --docker)")
+	flag.StringVar(&opts.OS, "os", runtime.GOOS, "Developer OS; windows also generates a build.ps1 alternative to the Makefile")
+	flag.BoolVar(&opts.Debug, "debug", false, "Trace resolved options, written files and subprocesses to stderr")
+	flag.BoolVar(&opts.TimeoutMiddleware, "timeout-middleware", false, "Enforce context.timeout from config as a per-request deadline (503 when exceeded)")
+	flag.StringVar(&opts.Description, "description", "", "One-line description for the READMEs and go.mod")
+	flag.Var(&opts.Require, "require", "Module to go get in new services before tidy, as module[@version] (repeatable)")
+	flag.BoolVar(&opts.Strict, "strict", false, "Exit non-zero when any step only produced a warning")
+	flag.StringVar(&opts.Config, "config", "file", "Service configuration: file (config.yaml) or code (compiled-in defaults)")
+	flag.BoolVar(&opts.Client, "client", false, "Generate a typed HTTP client package per service")
+	flag.BoolVar(&opts.GoWorkOff, "go-work-off", false, "Wire services to shared with replace directives only, without a go.work")
+	flag.StringVar(&opts.Deps, "deps", "", "Dependency update bot configuration: renovate or dependabot (default none)")
+	flag.BoolVar(&opts.ExampleCRUD, "example-crud", false, "Generate create/read/update/delete handlers, a repository and tests for the example table")
+	flag.StringVar(&opts.WorkspaceRoot, "workspace-root", "", "Join the existing go.work in this directory, relative to the project, instead of creating one")
+	flag.BoolVar(&opts.ToolVersions, "tool-versions", false, "Write a .tool-versions pinning golang to the detected patch version for asdf and mise")
+	flag.BoolVar(&opts.GRPCGateway, "grpc-gateway", false, "With --transport grpc, serve the .proto's REST mappings from the API through grpc-gateway")
+	flag.BoolVar(&opts.Health, "health", false, "Serve /healthz from the API and add a CLI healthcheck command, used as the Docker HEALTHCHECK")
+	flag.StringVar(&opts.OverwritePolicy, "overwrite-policy", "skip", "Existing files the run would change: skip (keep them), prompt (ask per file) or force (overwrite)")
+	flag.BoolVar(&opts.SeedData, "seed-data", false, "Generate db/seed.sql with sample rows, a CLI seed command and make seed-<service>")
+	flag.BoolVar(&opts.CheckModule, "check-module", false, "Warn when the project name is not an importable host/path module or its repository does not answer (uses the network)")
+	flag.StringVar(&opts.TestFramework, "test-framework", "stdlib", "Assertions of generated tests: stdlib (testing only) or testify")
+	flag.BoolVar(&opts.OTelLogs, "otel-logs", false, "With --structured-logging, add the OpenTelemetry trace_id and span_id of the context to log records")
+	flag.BoolVar(&opts.NoReadme, "no-readme", false, "Do not generate the project and service README.md files")
+	flag.BoolVar(&opts.NoMakefile, "no-makefile", false, "Do not generate the Makefile; targets are only added to an existing one")
+	flag.BoolVar(&opts.HTTPClient, "httpclient", false, "Add a shared/httpclient for outbound calls with timeouts, retries with backoff and rate limiting")
+	flag.BoolVar(&opts.Wizard, "wizard", false, "Choose the project, services and main options in an interactive terminal UI with a preview of the tree")
+	flag.BoolVar(&opts.GitHubMeta, "github-meta", false, "Generate .github/CODEOWNERS per service directory, a pull request template and issue templates")
+	flag.Var(&opts.DependsOn, "depends-on", "Service the new services import, wired through go.work and a replace directive (repeatable or comma-separated)")
+	flag.StringVar(&opts.LoadTest, "loadtest", "", "Load test script per service with a make loadtest-<service> target: k6 (default none)")
+	flag.BoolVar(&opts.ConfigValidate, "config-validate-on-load", false, "Validate required config fields when loading and stop services on invalid config")
+	flag.StringVar(&opts.Profile, "profile", "", "Named option set: rest-postgres, grpc-minimal or a profile of .creategorc; explicit flags override it")
+	flag.StringVar(&opts.GoDirective, "go-directive", "", "Go version of the go.mod go directives, as major.minor (default the installed Go)")
+	flag.StringVar(&opts.WorkspaceGo, "workspace-go", "", "Go version of the go.work go directive, as major.minor (default the installed Go)")
+	flag.BoolVar(&opts.ResetPorts, "reset-ports", false, "Reassign base+index ports to the existing services, in name order, before adding new ones")
+	flag.BoolVar(&opts.FeatureFlags, "feature-flags", false, "Add a shared/flags config-driven feature flag checker and a sample /beta route gated by it")
+	flag.BoolVar(&opts.ValidateTemplates, "validate-templates", false, "Parse every rendered Go file before writing it and stop on a template bug")
+	flag.BoolVar(&opts.Vendor, "vendor", false, "Vendor the dependencies after tidy (go work vendor, or go mod vendor per module), build with -mod=vendor and commit vendor/")
+	flag.StringVar(&opts.APIVersion, "api-version", "", "Serve the sample routes under /api/<version> (e.g. v1), with their handlers in an api/<version> package (default unversioned)")
+	flag.BoolVar(&opts.ShowConfig, "show-config", false, "Print the effective options, resolved from defaults, profile and flags, as YAML and exit without scaffolding")
+	flag.BoolVar(&opts.Helm, "helm", false, "Generate a Helm chart in deploy/charts/<project> with a Deployment and Service per service (implies --docker)")
+	flag.BoolVar(&opts.Atomic, "atomic", false, "Generate a new project in a temporary directory and move it into place only when every step succeeded")
+	flag.BoolVar(&opts.DDD, "ddd", false, "Organize service internals as a package per aggregate, named after the service (same as --arch ddd)")
+	flag.StringVar(&opts.Realtime, "realtime", "", "Streaming endpoint to add to the API: sse for server-sent events on /events, ws for a WebSocket echo on /ws (default none)")
+	flag.StringVar(&opts.TraceIDHeader, "trace-id-header", "X-Request-ID", "Header carrying the request ID read and set by middleware.RequestID, e.g. X-Correlation-ID or traceparent")
+	flag.StringVar(&opts.CIMatrix, "ci-matrix", "", "Comma-separated Go versions, e.g. 1.22,1.23, to build and test weekly in a GitHub Actions workflow (default none)")
+	flag.StringVar(&opts.ConfigFormat, "config-format", "yaml", "Format of the config files with --config file: yaml, or protobuf for a config.proto message loaded from config.txtpb")
+	flag.StringVar(&opts.Toolchain, "toolchain", "", "With bump-go, the toolchain line to write to go.work and every go.mod, e.g. go1.23.4 (default: keep it, dropping lines older than the new version)")
+	flag.BoolVar(&opts.ConfigCommands, "config-commands", false, "Register extra CLI commands declared under commands in the service config, each running a program")
+	flag.BoolVar(&opts.Procfile, "procfile", false, "Maintain a Procfile with one entry per service API")
+	flag.Uint64Var(&opts.Seed, "seed", 0, "Seed for randomized values, for byte-identical output (default random, 0 with --yes)")
+	flag.BoolVar(&opts.SkipTidy, "skip-tidy", false, "Skip go mod tidy (and the build check) to scaffold quickly or offline")
+	flag.BoolVar(&opts.Rollback, "rollback", false, "Remove what this run created if the generated code does not build")
+	flag.BoolVar(&opts.StructuredLogging, "structured-logging", false, "Generate slog-based logging with request IDs")
 
 	// Parse flags
 	flag.Parse()
+	recordCmdlineFlags()
+	if opts.Profile != "" {
+		applyProfile(opts.Profile)
+	}
+	// An existing project defaults to the options it was generated with
+	hasSavedOptions := projectName != "" && loadSavedOptions(projectName)
+
+	// Seed the generator: explicit --seed, a fixed base with --yes, random otherwise
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedGiven = true
+		}
+	})
+	seeded := opts.Yes || seedGiven
+	if !seeded {
+		var b [8]byte
+		if _, err := crand.Read(b[:]); err != nil {
+			log.Fatalf("❌ Failed to seed random generator: %v", err)
+		}
+		opts.Seed = binary.LittleEndian.Uint64(b[:])
+	}
+	rng = rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+
+	if opts.LayoutFile != "" {
+		layout = loadLayout(opts.LayoutFile)
+	}
+
+	if opts.Wizard {
+		if opts.Yes || command != "" {
+			log.Fatal("❌ --wizard is interactive and cannot be combined with --yes or a subcommand.")
+		}
+		if err := runWizard(&projectName, serviceName); err != nil {
+			log.Fatalf("❌ Wizard %v.", err)
+		}
+	}
+
+	// Compose and the Helm chart run the images built from the Dockerfiles
+	if opts.Compose || opts.Helm {
+		opts.Docker = true
+	}
+
+	// Compiled-in config is only tunable through the environment
+	if opts.Config == "code" && opts.EnvPrefix == "" {
+		opts.EnvPrefix = "APP"
+	}
+	opts.EnvPrefix = strings.ToUpper(opts.EnvPrefix)
+	if strings.Trim(opts.EnvPrefix, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_") != "" {
+		log.Fatalf("❌ Invalid env prefix %q, expected letters, digits and underscores.", opts.EnvPrefix)
+	}
+
+	// Existing single-module and workspace-free projects keep their layout
+	if _, err := os.Stat(filepath.Join(projectName, "go.mod")); err == nil && projectName != "" {
+		opts.SingleModule = true
+	} else if _, err := os.Stat(filepath.Join(projectName, "shared", "go.mod")); err == nil && projectName != "" {
+		// Without a go.work of its own the project either joined a parent
+		// workspace with --workspace-root or uses replace directives only
+		if _, err := os.Stat(filepath.Join(projectName, "go.work")); os.IsNotExist(err) && opts.WorkspaceRoot == "" {
+			opts.WorkspaceRoot = parentWorkspace(projectName)
+			opts.GoWorkOff = opts.WorkspaceRoot == ""
+		}
+	}
+
+	checkOptions()
+	debugOptions()
+	if opts.ShowConfig {
+		showConfig(projectName)
+		return
+	}
 
-	// If skipPrompt is true, use default values for project and service
-	if *skipPrompt {
+	if command == "update" {
+		if projectName == "" {
+			log.Fatal("❌ Usage: create-go-project <project_name> update")
+		}
+		if !hasSavedOptions {
+			warnf("%s has no %s: the files are rendered from the flags of this run only", projectName, optionsFile)
+		}
+		updateProject(projectName)
+		formatCode(projectName)
+		printTidyReminder()
+		exitIfWarned()
+		return
+	}
+
+	if command == "bump-go" {
+		if projectName == "" || bumpVersion == "" {
+			log.Fatal("❌ Usage: create-go-project <project_name> bump-go <go_version>")
+		}
+		bumpGo(projectName, bumpVersion)
+		exitIfWarned()
+		return
+	}
+
+	if command == "sync" {
+		if projectName == "" {
+			log.Fatal("❌ Usage: create-go-project <project_name> sync")
+		}
+		syncProject(projectName)
+		exitIfWarned()
+		return
+	}
+
+	// With --yes, use default values for project and service
+	if opts.Yes {
 		if projectName == "" {
 			projectName = "microservice"
 		}
@@ -43,19 +411,17 @@ func main() {
 		fmt.Println("⚙️  Using defaults: project =", projectName, ", service =", *serviceName)
 	} else {
 		// Otherwise, interactively ask for names
-		reader := bufio.NewReader(os.Stdin)
-
 		// Prompt for project name if not supplied
 		if projectName == "" {
 			fmt.Print("📝 Enter project name: ")
-			input, _ := reader.ReadString('\n')
+			input, _ := stdin.ReadString('\n')
 			projectName = strings.TrimSpace(input)
 		}
 
 		// Prompt for service name if not supplied
 		if *serviceName == "" {
 			fmt.Print("🛠️  Enter service name (e.g. user, billing): ")
-			input, _ := reader.ReadString('\n')
+			input, _ := stdin.ReadString('\n')
 			*serviceName = strings.TrimSpace(input)
 		}
 	}
@@ -65,102 +431,367 @@ func main() {
 		log.Fatal("❌ Project and service names are required.")
 	}
 
-	if _, err := os.Stat(projectName); err == nil {
+	// Several services can be scaffolded at once: --service user,billing
+	var services []string
+	for _, name := range strings.Split(*serviceName, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			services = append(services, name)
+		}
+	}
+	services = dedup(services)
+	if len(services) == 0 {
+		log.Fatal("❌ Project and service names are required.")
+	}
+
+	if opts.WorkspaceRoot != "" {
+		if opts.SingleModule || opts.GoWorkOff {
+			log.Fatal("❌ --workspace-root needs a module per service, without --single-module or --go-work-off.")
+		}
+		if _, err := os.Stat(filepath.Join(workspaceDir(projectName), "go.work")); err != nil && !deferWorkspace() {
+			log.Fatalf("❌ No go.work in %s, the --workspace-root of %s.", workspaceDir(projectName), projectName)
+		}
+	}
+
+	checkAggregates(services)
+	checkServiceTypes(services)
+	if opts.Auth != "" && opts.Auth != "jwt" {
+		log.Fatalf("❌ Unknown auth %q, expected jwt.", opts.Auth)
+	}
+
+	checkAtomic(projectName)
+
+	if opts.CheckModule {
+		checkModule(projectName)
+	}
+
+	// Services are created after the new services they depend on
+	services, err := orderServices(projectName, services)
+	if err != nil {
+		log.Fatalf("❌ Invalid --depends-on: %v.", err)
+	}
+
+	if _, err := os.Stat(projectName); err == nil && atomicChild() {
+		// A staged copy of the project, reviewed by the parent run
+		if opts.ResetPorts {
+			resetPorts(projectName)
+		}
+		createServices(projectName, services)
+	} else if err == nil {
 		log.Printf("Project %s already exists, skipping project creation.", projectName)
-		createService(projectName, *serviceName)
+		checkSharedCurrent(projectName)
+		stageServices(projectName, services)
+		exitIfWarned()
+		return
+	} else if opts.Atomic && !atomicChild() {
+		generateAtomically(projectName, services)
+		return
 	} else {
 		// Proceed with the project creation
-		createProject(projectName, *serviceName)
+		createProject(projectName, services)
 	}
 
 	formatCode(projectName)
+
+	if opts.PrintTree {
+		fmt.Println()
+		if err := printTree(os.Stdout, projectName); err != nil {
+			warnf("Failed to print the project tree: %v", err)
+		}
+	}
+
+	printTidyReminder()
+	exitIfWarned()
 }
 
-func createProject(project, service string) {
-	// List of directories to create
-	baseDirs := []string{
-		"shared/config",
-		"deploy",
+// checkOptions normalizes the options and stops on invalid values, before
+// --show-config prints them or anything is generated
+func checkOptions() {
+	if opts.Transport != "http" && opts.Transport != "grpc" && opts.Transport != "graphql" {
+		log.Fatalf("❌ Unknown transport %q, expected http, grpc or graphql.", opts.Transport)
 	}
 
-	// Create directories
-	for _, dir := range baseDirs {
-		fullPath := filepath.Join(project, dir)
+	if opts.GRPCGateway && opts.Transport != "grpc" {
+		log.Fatal("❌ --grpc-gateway needs --transport grpc.")
+	}
+
+	if opts.OTelLogs && !opts.StructuredLogging {
+		log.Fatal("❌ --otel-logs needs --structured-logging.")
+	}
+
+	if opts.OverwritePolicy != "skip" && opts.OverwritePolicy != "prompt" && opts.OverwritePolicy != "force" {
+		log.Fatalf("❌ Unknown overwrite policy %q, expected skip, prompt or force.", opts.OverwritePolicy)
+	}
+
+	if opts.TestFramework != "stdlib" && opts.TestFramework != "testify" {
+		log.Fatalf("❌ Unknown test framework %q, expected stdlib or testify.", opts.TestFramework)
+	}
+
+	if opts.LoadTest != "" && opts.LoadTest != "k6" {
+		log.Fatalf("❌ Unknown load test tool %q, expected k6.", opts.LoadTest)
+	}
+	checkGoVersions()
+	checkCIMatrix()
+	if opts.APIVersion != "" && !apiVersionPattern.MatchString(opts.APIVersion) {
+		log.Fatalf("❌ Invalid --api-version %q, expected v followed by a number such as v1.", opts.APIVersion)
+	}
+	if opts.Realtime != "" && opts.Realtime != "sse" && opts.Realtime != "ws" {
+		log.Fatalf("❌ Unknown realtime endpoint %q, expected sse or ws.", opts.Realtime)
+	}
+	if !traceIDHeaderPattern.MatchString(opts.TraceIDHeader) {
+		log.Fatalf("❌ Invalid --trace-id-header %q, expected an HTTP header name such as X-Correlation-ID.", opts.TraceIDHeader)
+	}
+	if opts.Vendor && opts.SkipTidy {
+		log.Fatal("❌ --vendor vendors the tidied dependencies and cannot be combined with --skip-tidy.")
+	}
+
+	if opts.Config != "file" && opts.Config != "code" {
+		log.Fatalf("❌ Unknown config %q, expected file or code.", opts.Config)
+	}
+	if opts.ConfigFormat != "yaml" && opts.ConfigFormat != "protobuf" {
+		log.Fatalf("❌ Unknown config format %q, expected yaml or protobuf.", opts.ConfigFormat)
+	}
+	if protobufConfig() && configInCode() {
+		log.Fatal("❌ --config-format protobuf describes config files and cannot be combined with --config code.")
+	}
+
+	if opts.Deps != "" && opts.Deps != "renovate" && opts.Deps != "dependabot" {
+		log.Fatalf("❌ Unknown deps bot %q, expected renovate or dependabot.", opts.Deps)
+	}
+
+	if opts.Runner != "make" && opts.Runner != "just" {
+		log.Fatalf("❌ Unknown runner %q, expected make or just.", opts.Runner)
+	}
+
+	if opts.CI != "" && opts.CI != "gitlab" {
+		log.Fatalf("❌ Unknown CI system %q, expected gitlab.", opts.CI)
+	}
+
+	if strings.ContainsAny(opts.Description, "\r\n") {
+		log.Fatal("❌ The description must be a single line.")
+	}
+	if opts.Cache != "" && opts.Cache != "redis" {
+		log.Fatalf("❌ Unknown cache %q, expected redis.", opts.Cache)
+	}
+	if opts.Messaging != "" && opts.Messaging != "nats" {
+		log.Fatalf("❌ Unknown messaging %q, expected nats.", opts.Messaging)
+	}
+	if _, _, err := serviceTypes(); err != nil {
+		log.Fatalf("❌ Invalid --type: %v.", err)
+	}
+	if opts.DDD {
+		if opts.Arch != "flat" && opts.Arch != "ddd" {
+			log.Fatalf("❌ --ddd cannot be combined with --arch %s.", opts.Arch)
+		}
+		opts.Arch = "ddd"
+	}
+	if opts.Arch != "flat" && opts.Arch != "clean" && opts.Arch != "ddd" {
+		log.Fatalf("❌ Unknown arch %q, expected flat, clean or ddd.", opts.Arch)
+	}
+}
 
+func createProject(project string, services []string) {
+	service := services[0]
+
+	rollbackPaths = append(rollbackPaths, project)
+
+	// List of directories to create
+	baseDirs := layoutDirs(layout.project(), project, []string{
+		filepath.Join(project, "shared/appctx"),
+		filepath.Join(project, "shared/config"),
+		filepath.Join(project, "shared/middleware"),
+		filepath.Join(project, "shared/version"),
+		filepath.Join(project, "shared/shutdown"),
+		filepath.Join(project, "deploy"),
+	})
+
+	// Create directories
+	for _, fullPath := range baseDirs {
 		if err := os.MkdirAll(fullPath, 0755); err != nil {
 			log.Fatalf("Error creating directory %s: %v", fullPath, err)
 		}
 	}
 
 	// Add initial files in the project
-	writeFile(project, "go.work", fmt.Sprintf(`go %s
-	`, goVer))
+	if opts.SingleModule {
+		writeFile(project, "go.mod", fmt.Sprintf(`%smodule %s
+
+go %s
+`, descriptionComment(), project, modGoVersion()))
+	} else {
+		if !goWorkOff() && opts.WorkspaceRoot == "" {
+			writeFile(project, "go.work", fmt.Sprintf(`go %s
+	`, workGoVersion()))
+		}
+
+		writeFile(filepath.Join(project, "shared"), "go.mod", fmt.Sprintf(`module %s/shared
+
+go %s`, project, modGoVersion()))
+	}
+
+	if !opts.NoMakefile {
+		writeFile(project, "Makefile", fmt.Sprintf(`VERSION ?= $(shell git describe --tags --always --dirty 2>/dev/null || echo dev)
+COMMIT ?= $(shell git rev-parse --short HEAD 2>/dev/null || echo none)
+BUILD_TIME ?= $(shell date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)
+LDFLAGS := -X %[1]s/shared/version.Version=$(VERSION) -X %[1]s/shared/version.Commit=$(COMMIT) -X %[1]s/shared/version.BuildTime=$(BUILD_TIME)
+
+.DEFAULT_GOAL := help
 
-	writeFile(project, "Makefile", fmt.Sprintf(`build:
-	go build -o bin/%s-cli ./services/%s/cmd/cli/main.go
-	go build -o bin/%s-api ./services/%s/cmd/api/main.go
+# Targets followed by "## description" are listed by make help
+help: ## List the available targets
+	@awk 'BEGIN {FS = ":.*## "} /^[a-zA-Z0-9_.-]+:.*## / {printf "  %%-28s %%s\n", $$1, $$2}' $(MAKEFILE_LIST)
 
-`, service, service, service, service))
+build: ## Build every service
+%[2]s
+`, project, buildLines(services, func(service string) bool { return serviceType(service) == "worker" })))
+	}
 
-	writeFile(project, "README.md", fmt.Sprintf(`# %s
+	intro := fmt.Sprintf("Generated with [create-go-project](%s) %s. Refresh the shared files with `create-go-project %s update`.", toolURL, toolVersion(), project)
+	if opts.Description != "" {
+		intro = opts.Description + "\n\n" + intro
+	}
+	if !opts.NoReadme {
+		writeFile(project, "README.md", fmt.Sprintf(`# %s
 
-Generated with create-go-app.
+%s
 
 Includes:
+- shared/apierror
+- shared/appctx
 - shared/config
-- services/%s (API, CLI)
-`, project, service))
+- shared/middleware
+- shared/version
+- shared/pagination
+- shared/shutdown
+- %s (%s)
+`, project, intro, serviceRel(service), entrypointsLabel(project, service)))
+	}
 
-	writeFile(filepath.Join(project, "shared"), "go.mod", fmt.Sprintf(`module %s/shared
+	writeFile(filepath.Join(project, "shared/apierror"), "apierror.go", apierrorSource)
 
-go %s`, project, goVer))
+	writeFile(filepath.Join(project, "shared/appctx"), "appctx.go", appctxSource)
 
-	const configTpl = `package config
+	writeFile(filepath.Join(project, "shared/config"), "config.go", configSource(project))
 
-import (
-	"os"
-	"time"
+	writeFile(filepath.Join(project, "shared/middleware"), "middleware.go", middlewareSource(project))
 
-	"gopkg.in/yaml.v2"
-)
+	writeFile(filepath.Join(project, "shared/version"), "version.go", versionSource)
 
-type Config struct {
-	Database struct {
-		Driver          string        §yaml:"driver"§
-		Host            string        §yaml:"host"§
-		Port            int           §yaml:"port"§
-		User            string        §yaml:"user"§
-		Password        string        §yaml:"password"§
-		Dbname          string        §yaml:"dbname"§
-		Sslmode         string        §yaml:"sslmode"§
-		MaxOpenConns    int           §yaml:"maxOpenConns"§
-		MaxIdleConns    int           §yaml:"maxIdleConns"§
-		ConnMaxLifetime time.Duration §yaml:"connMaxLifetime"§
-	} §yaml:"database"§
-	Context struct {
-		Timeout time.Duration §yaml:"timeout"§
-	} §yaml:"context"§
-	Server struct {
-		Port int §yaml:"port"§
-	} §yaml:"server"§
-}
-
-func LoadConfig(service string) (*Config, error) {
-	data, err := os.ReadFile("./services/" + service + "/config/config.yaml")
-	if err != nil {
-		return nil, err
+	writeFile(filepath.Join(project, "shared/pagination"), "pagination.go", paginationSource)
+
+	writeFile(filepath.Join(project, "shared/shutdown"), "shutdown.go", shutdownSource)
+
+	if opts.Messaging == "nats" {
+		writeFile(filepath.Join(project, "shared/messaging"), "messaging.go", messagingSource())
 	}
-	var config Config
-	err = yaml.Unmarshal(data, &config)
-	if err != nil {
-		return nil, err
+
+	if opts.Cache == "redis" {
+		writeFile(filepath.Join(project, "shared/cache"), "cache.go", cacheSource())
+	}
+
+	if opts.OTelLogs {
+		writeFile(filepath.Join(project, "shared/logging"), "trace.go", traceHandlerSource)
+	}
+
+	if opts.HTTPClient {
+		writeFile(filepath.Join(project, "shared/httpclient"), "httpclient.go", httpclientSource)
+	}
+
+	if opts.FeatureFlags {
+		writeFile(filepath.Join(project, "shared/flags"), "flags.go", flagsSource)
+	}
+
+	if opts.Validation {
+		writeFile(filepath.Join(project, "shared/validate"), "validate.go", validateSource)
+	}
+
+	writeFile(project, ".gitignore", gitignoreContent())
+
+	if opts.EnvPrefix != "" {
+		writeFile(project, ".env.example", envExample())
+	}
+
+	writeLayoutFiles(layout.project(), project, projectTemplates, templateData{Project: project, GoVersion: modGoVersion()})
+
+	if opts.OS == "windows" {
+		createBuildScript(project)
 	}
-	return &config, nil
+
+	if opts.ReleaseTooling {
+		createReleaseTooling(project, service)
+	}
+
+	createCI(project)
+
+	if opts.GitHubMeta {
+		createGitHubMeta(project)
+	}
+
+	if opts.Nix {
+		createFlake(project)
+	}
+
+	// asdf and mise install the pinned toolchain on entering the project
+	if opts.ToolVersions {
+		writeFile(project, ".tool-versions", fmt.Sprintf("golang %s\n", goPatchVer))
+	}
+
+	saveOptions(project)
+
+	// Initialize Git repo, unless the project joins an existing monorepo
+	if opts.WorkspaceRoot != "" {
+		fmt.Println("⏭️  Skipped git init: the project is part of the workspace at", workspaceDir(project))
+	} else if err := runCmd(project, "git", "init"); err != nil {
+		warnf("Failed to initialize Git repo: %v", err)
+	} else {
+		fmt.Println("📦 Git repository initialized.")
+	}
+
+	// The config loader imports the generated message, needed to tidy shared
+	if protobufConfig() {
+		createConfigProto(project)
+	}
+
+	// Run go mod tidy in shared folder
+	if !opts.SingleModule {
+		goModTidy(filepath.Join(project, "shared"))
+	}
+
+	// Create initial service files
+	createServices(project, services)
+
+	// Final message
+	label := "service"
+	if len(services) > 1 {
+		label = "services"
+	}
+	fmt.Printf("\n✅ Project '%s' created with %s '%s'\n", project, label, strings.Join(services, "', '"))
+	if opts.OS == "windows" {
+		fmt.Println("🪟 Without make, use .\\build.ps1 [build|test|tidy|run]")
+	}
+	fmt.Printf("📁 cd %s\n", project)
+	fmt.Println("🚀 You're ready to start building!")
+}
+
+// versionSource is shared/version/version.go, stamped by the Makefile LDFLAGS
+const versionSource = `// Package version holds build metadata injected with -ldflags at build time
+package version
+
+var (
+	Version   = "dev"
+	Commit    = "none"
+	BuildTime = "unknown"
+)
+
+// String formats the build metadata on a single line
+func String() string {
+	return Version + " (commit " + Commit + ", built " + BuildTime + ")"
 }
 `
-	writeFile(filepath.Join(project, "shared/config"), "config.go", renderTemplate(configTpl, '§'))
 
-	writeFile(project, ".gitignore", `.DS_Store
+// gitignoreContent renders the project .gitignore: the common Go and editor
+// patterns, then a section per enabled feature leaving artifacts behind
+func gitignoreContent() string {
+	content := `.DS_Store
 bin/
 *.log
 *.test
@@ -176,29 +807,28 @@ coverage.out
 .idea/
 .env
 .env.*
-`)
-	// Initialize Git repo
-	if err := runCmd(project, "git", "init"); err != nil {
-		log.Printf("⚠️ Failed to initialize Git repo: %v", err)
-	} else {
-		fmt.Println("📦 Git repository initialized.")
+!.env.example
+`
+	content = vendorIgnored(content)
+	section := func(comment string, patterns ...string) {
+		content += "\n# " + comment + "\n" + strings.Join(patterns, "\n") + "\n"
 	}
-
-	// Run go mod tidy in shared folder
-	sharedPath := filepath.Join(project, "shared")
-	if err := runCmd(sharedPath, "go", "mod", "tidy"); err != nil {
-		log.Printf("⚠️ Failed to run in shared 'go mod tidy': %v", err)
-	} else {
-		fmt.Println("🧹 go mod tidy run inside shared")
+	if opts.Tests {
+		section("Coverage and benchmark profiles (--tests)", "coverage.html", "*.coverprofile", "cpu.prof", "mem.prof")
 	}
-
-	// Create initial service files
-	createService(project, service)
-
-	// Final message
-	fmt.Printf("\n✅ Project '%s' created with service '%s'\n", project, service)
-	fmt.Printf("📁 cd %s\n", project)
-	fmt.Println("🚀 You're ready to start building!")
+	if opts.Pprof {
+		section("Profiles downloaded from /debug/pprof (--pprof)", "*.prof", "*.pprof", "*.pb.gz")
+	}
+	if opts.ReleaseTooling {
+		section("Release binaries (--release-tooling)", "dist/")
+	}
+	if opts.Compose {
+		section("Local docker compose overrides (--compose)", "docker-compose.override.yml")
+	}
+	if opts.Nix {
+		section("nix build outputs and direnv cache (--nix)", "result", "result-*", ".direnv/")
+	}
+	return content
 }
 
 // Replace placeholder with backtick
@@ -206,34 +836,261 @@ func renderTemplate(template string, placeholder rune) string {
 	return strings.ReplaceAll(template, string(placeholder), "`")
 }
 
+// goSource assembles a Go file from its package name, standard library
+// imports, module imports and body, and gofmts the result when it parses
+func goSource(pkg string, std, mods []string, body string) string {
+	debugf("render package %s (%d imports)", pkg, len(std)+len(mods))
+	src := fmt.Sprintf("package %s\n", pkg)
+	if len(std)+len(mods) > 0 {
+		src += "\n" + importBlock(std, mods)
+	}
+	return formatGo(src + "\n" + body)
+}
+
+// importBlock renders an import declaration with the standard library group
+// first, separated from the module imports
+func importBlock(std, mods []string) string {
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, imp := range dedup(std) {
+		fmt.Fprintf(&b, "\t%s\n", quoteImport(imp))
+	}
+	if len(std) > 0 && len(mods) > 0 {
+		b.WriteString("\n")
+	}
+	for _, imp := range dedup(mods) {
+		fmt.Fprintf(&b, "\t%s\n", quoteImport(imp))
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// formatGo gofmts generated source, leaving it untouched if it does not parse
+func formatGo(src string) string {
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return src
+	}
+	return string(out)
+}
+
+// quoteImport quotes an import path, keeping an optional "name path" alias
+func quoteImport(imp string) string {
+	if name, path, ok := strings.Cut(imp, " "); ok {
+		return name + " " + strconv.Quote(path)
+	}
+	return strconv.Quote(imp)
+}
+
+func dedup(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	var out []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// writtenFiles lists the files written by this run, which the overwrite
+// policy lets later steps replace
+var writtenFiles = map[string]bool{}
+
+// writeFile writes a generated file. Files that existed before this run
+// are only replaced as --overwrite-policy allows.
 func writeFile(base, name, content string) {
 	path := filepath.Join(base, name)
+	validateGo(path, content)
+	if !mayOverwrite(path, content) {
+		return
+	}
+	saveFile(path, content)
+}
+
+// rewriteFile writes a file regardless of the overwrite policy, for files
+// the tool re-renders by design such as update's owned files
+func rewriteFile(base, name, content string) {
+	path := filepath.Join(base, name)
+	validateGo(path, content)
+	saveFile(path, content)
+}
+
+// validateGo parses a rendered Go file with --validate-templates, stopping
+// before a template bug such as a misplaced fmt verb reaches disk
+func validateGo(path, content string) {
+	if !opts.ValidateTemplates || filepath.Ext(path) != ".go" {
+		return
+	}
+	_, err := parser.ParseFile(token.NewFileSet(), path, content, parser.AllErrors)
+	if err == nil && strings.Contains(content, "%!") {
+		err = errors.New("it contains a fmt formatting error (%!)")
+	}
+	if err == nil {
+		return
+	}
+	if opts.Rollback {
+		rollback()
+	}
+	log.Fatalf("❌ Template bug: %s is not valid Go:\n%v", path, err)
+}
+
+// saveFile writes content to path, creating its directory
+func saveFile(path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Fatalf("Error creating directory %s: %v", filepath.Dir(path), err)
+	}
 	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 		log.Fatalf("Error writing file %s: %v", path, err)
 	}
+	writtenFiles[path] = true
+	debugf("write %s (%d bytes)", path, len(content))
+}
+
+// mayOverwrite applies --overwrite-policy to a file that existed before
+// this run with different content: skip keeps it, prompt asks and force
+// replaces it
+func mayOverwrite(path, content string) bool {
+	if writtenFiles[path] {
+		return true
+	}
+	current, err := os.ReadFile(path)
+	if err != nil || string(current) == content {
+		return true
+	}
+	switch opts.OverwritePolicy {
+	case "force":
+		return true
+	case "prompt":
+		if confirm(fmt.Sprintf("%s already exists and will be overwritten.", path)) {
+			return true
+		}
+	}
+	fmt.Println("⏭️  Kept existing", path)
+	return false
 }
 
 func runCmd(dir string, name string, args ...string) error {
+	return runCmdTo(os.Stdout, os.Stderr, dir, name, args...)
+}
+
+// runCmdTo is runCmd with the output sent to the given writers; go commands
+// get the --go-env environment
+func runCmdTo(stdout, stderr io.Writer, dir string, name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	if name == "go" && len(opts.GoEnv) > 0 {
+		cmd.Env = append(os.Environ(), opts.GoEnv...)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return runTraced(cmd)
+}
+
+// stdin reads interactive answers; shared so buffered input is not lost
+// between prompts
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question before a destructive operation, defaulting
+// to no. --yes answers yes without asking.
+func confirm(question string) bool {
+	if opts.Yes {
+		return true
+	}
+	fmt.Printf("❓ %s Continue? [y/N] ", question)
+	answer, _ := stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// warned records that a non-fatal step failed, checked by --strict. Modules
+// are resolved concurrently, hence atomic.
+var warned atomic.Bool
+
+// warnf logs a step that failed without stopping generation
+func warnf(format string, args ...any) {
+	warned.Store(true)
+	log.Printf("⚠️ "+format, args...)
 }
 
-func getGoVersion() string {
+// exitIfWarned fails the run when --strict is set and a step only warned
+func exitIfWarned() {
+	if opts.Strict && warned.Load() {
+		log.Fatal("❌ Some steps failed with warnings; exiting because of --strict.")
+	}
+}
+
+// goModTidy runs go mod tidy in dir, or defers it when --skip-tidy is set
+func goModTidy(dir string) {
+	if opts.SkipTidy {
+		skippedTidy = append(skippedTidy, dir)
+		return
+	}
+	if err := runCmd(dir, "go", "mod", "tidy"); err != nil {
+		warnf("Failed to run 'go mod tidy' in %s: %v", dir, err)
+	} else {
+		fmt.Println("🧹 go mod tidy run inside", dir)
+	}
+}
+
+// printTidyReminder lists the modules left untidied by --skip-tidy
+func printTidyReminder() {
+	if len(skippedTidy) == 0 {
+		return
+	}
+	fmt.Println("\n⏭️  Skipped go mod tidy. Run it before building:")
+	for _, dir := range dedup(skippedTidy) {
+		fmt.Printf("   (cd %s && go mod tidy)\n", dir)
+	}
+}
+
+// verifyBuild compiles every package of a module on its own, outside the
+// workspace, to surface dependencies go mod tidy could not resolve
+func verifyBuild(out io.Writer, dir string) error {
+	return runGoOutsideWorkspace(out, dir, "build", "./...")
+}
+
+// runGoOutsideWorkspace runs a go command in dir with GOWORK=off, for
+// modules not yet listed in go.work
+func runGoOutsideWorkspace(out io.Writer, dir string, args ...string) error {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), opts.GoEnv...), "GOWORK=off")
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return runTraced(cmd)
+}
+
+// rollback removes every directory this run created
+func rollback() {
+	for i := len(rollbackPaths) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(rollbackPaths[i]); err != nil {
+			warnf("Failed to remove %s: %v", rollbackPaths[i], err)
+		} else {
+			fmt.Println("🗑️  Removed", rollbackPaths[i])
+		}
+	}
+}
+
+func getGoVersion() (string, string) {
 	// Get and print the Go version
 	goVersionCmd := exec.Command("go", "version")
 	output, err := goVersionCmd.Output()
-	goVer := ""
+	goVer, patchVer := "", ""
 
 	if err != nil {
 		log.Fatalf("❌ Failed to get Go version: %v", err)
 	} else {
 		goVer = strings.TrimSpace(string(output))
+		patchVer = goVer
 		parts := strings.Fields(goVer)
 		if len(parts) >= 3 {
-			versionParts := strings.Split(parts[2][2:], ".")
+			patchVer = strings.TrimPrefix(parts[2], "go")
+			versionParts := strings.Split(patchVer, ".")
 			if len(versionParts) > 1 {
 				goVer = versionParts[0] + "." + versionParts[1]
 			}
@@ -241,169 +1098,538 @@ func getGoVersion() string {
 		fmt.Printf("✅ Go version: %s\n", goVer)
 	}
 
-	return goVer
+	return goVer, patchVer
 }
 
-func createService(project, service string) {
-	// List of directories to create
-	baseDirs := []string{
-		fmt.Sprintf("services/%s/api", service),
-		fmt.Sprintf("services/%s/cli", service),
-		fmt.Sprintf("services/%s/cmd/api", service),
-		fmt.Sprintf("services/%s/cmd/cli", service),
-		fmt.Sprintf("services/%s/config", service),
-		fmt.Sprintf("services/%s/db", service),
-		fmt.Sprintf("services/%s/internal/service", service),
+// createServices scaffolds every service, resolves their modules in parallel
+// and then wires them into the project one at a time
+func createServices(project string, services []string) {
+	var modules []string
+	for _, service := range services {
+		scaffoldService(project, service)
+		modules = append(modules, moduleDir(project, service))
 	}
-	// Create directories
-	for _, dir := range baseDirs {
-		fullPath := filepath.Join(project, dir)
 
-		if err := os.MkdirAll(fullPath, 0755); err != nil {
-			log.Fatalf("Error creating directory %s: %v", fullPath, err)
+	modules = dedup(modules)
+	if err := resolveModules(modules); err != nil {
+		if opts.Rollback {
+			rollback()
 		}
+		if errors.Is(err, errUnresolved) {
+			hint := "then run 'go mod tidy' in the failing module"
+			// A staged or --atomic run discards the module
+			if opts.Rollback || atomicChild() {
+				hint = "then run the tool again"
+			}
+			log.Fatalf("❌ Generated code does not build:\n%v\n"+
+				"   Dependencies did not resolve; check network access and GOPROXY, %s.", err, hint)
+		}
+		log.Fatalf("❌ Generated code does not compile:\n%v\n"+
+			"   This is a create-go-project bug: please report it with the compiler output above.", err)
 	}
 
-	writeFile(filepath.Join(project, "services", service), "go.mod", fmt.Sprintf(`module %s/%s
+	for _, service := range services {
+		finishService(project, service)
+	}
+	vendorModules(project, modules)
+	addVendorTarget(project)
 
-go %s
-`, project, service, goVer))
+	writeDepsConfig(project)
+	runPlugins(project, services)
+}
 
-	// Create service files
-	writeFile(filepath.Join(project, "services", service, "cmd/api"), "main.go", fmt.Sprintf(`package main
+// scaffoldService writes the files of a service and prepares its module
+func scaffoldService(project, service string) {
+	servicePath := serviceDir(project, service)
+	if _, err := os.Stat(servicePath); os.IsNotExist(err) {
+		rollbackPaths = append(rollbackPaths, servicePath)
+	}
 
-import (
-	"fmt"
-	"log"
-	"net/http"
-	"%s/shared/config"
-	"%s/%s/api"
-)
+	// The Nth service listens on base+N, unless that port is taken; an
+	// existing service keeps its port
+	port := assignPort(project, service)
+	index := port - opts.BasePort
 
-func main() {
-	port := 8081
-	config, err := config.LoadConfig("%s")
-	if err == nil {
-		port = config.Server.Port
+	// List of directories to create
+	baseDirs := layoutDirs(layout.service(), servicePath, []string{
+		servicePackage(project, service, "api"),
+		servicePackage(project, service, "cli"),
+		servicePackage(project, service, "config"),
+		servicePackage(project, service, "db"),
+		servicePackage(project, service, serviceCorePackage(service)),
+		cmdDir(project, service, "api"),
+		cmdDir(project, service, "cli"),
+	})
+	// Create directories
+	for _, fullPath := range baseDirs {
+		if err := os.MkdirAll(fullPath, 0755); err != nil {
+			log.Fatalf("Error creating directory %s: %v", fullPath, err)
+		}
 	}
 
-	http.HandleFunc("/hello", api.HelloHandler)
-	log.Printf("🔌 API server running at :%s\n", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
-}
-`, project, project, service, service, "%d", "%d"))
+	if !opts.SingleModule {
+		writeFile(servicePath, "go.mod", fmt.Sprintf(`%smodule %s/%s
 
-	writeFile(filepath.Join(project, "services", service, "cmd/cli"), "main.go", fmt.Sprintf(`package main
+go %s
+`, descriptionComment(), project, service, modGoVersion()))
+	}
+
+	// Create service files
+	writeFile(cmdDir(project, service, "api"), "main.go", apiMainSource(project, service))
+
+	writeFile(cmdDir(project, service, "cli"), "main.go", fmt.Sprintf(`package main
 
 import (
-	"%s/%s/cli"
+	"%s"
 )
 
 func main() {
 	cli.Execute()
 }
-`, project, service))
+`, serviceImport(project, service, "cli")))
 
-	writeFile(filepath.Join(project, "services", service, "api"), "handlers.go", fmt.Sprintf(`package api
+	if !layeredArch() {
+		writeFile(servicePackage(project, service, handlersRel()), "handlers.go", fmt.Sprintf(`package %s
 
 import (
 	"fmt"
 	"net/http"
-	"%s/%s/internal/service"
+	"%s"
 )
 
 func HelloHandler(w http.ResponseWriter, r *http.Request) {
 	greeting := "👋 Hello from the %s API"
 	if name := r.URL.Query().Get("name"); name != "" {
-		greeting = service.Greet(name)
+		var err error
+		if greeting, err = service.Greet(r.Context(), name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 
 	fmt.Fprintln(w, greeting + "!")
 }
-`, project, service, service))
+`, handlersPkg(), serviceImport(project, service, "internal/service"), service))
+
+		writeFile(servicePackage(project, service, handlersRel()), "greet.go", greetSource(project, service))
+	}
+
+	writeFile(servicePackage(project, service, "api"), "version.go", goSource("api",
+		[]string{"encoding/json", "net/http"},
+		[]string{project + "/shared/version"},
+		`// VersionHandler reports the build metadata stamped into the binary
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"version":   version.Version,
+		"commit":    version.Commit,
+		"buildTime": version.BuildTime,
+	})
+}
+`))
+
+	writeFile(servicePackage(project, service, handlersRel()), "items.go", itemsSource(project))
+
+	if opts.Cache == "redis" {
+		writeFile(servicePackage(project, service, "api"), "ready.go", readySource())
+	}
+
+	if opts.Health {
+		writeFile(servicePackage(project, service, "api"), "health.go", healthSource)
+		writeFile(servicePackage(project, service, "cli"), "healthcheck.go", healthcheckCmdSource(project, service))
+	}
+
+	if opts.Auth == "jwt" {
+		writeFile(servicePackage(project, service, "api"), "auth.go", authSource(project, service))
+	}
 
-	writeFile(filepath.Join(project, "services", service, "cli"), "root.go", fmt.Sprintf(`package cli
+	writeFile(servicePackage(project, service, "api"), "middleware.go", fmt.Sprintf(`package api
 
 import (
-	"fmt"
-	"github.com/spf13/cobra"
-	"%s/%s/internal/service"
+	"net/http"
+	"%s/shared/middleware"
 )
 
-var rootCmd = &cobra.Command{
+// Wrap installs the service middleware stack around the router, outermost
+// first. Recovery comes first so a panic anywhere below still yields a 500,
+// and the request ID is set before Logging reads it.
+func Wrap(h http.Handler) http.Handler {
+	return middleware.Chain(h,
+		middleware.Recovery,
+		middleware.RequestID,
+		middleware.Logging,
+	)
+}
+`, project))
+
+	// Config commands are added once every built-in command is registered
+	addCommands := ""
+	if opts.ConfigCommands {
+		addCommands = "\n\taddConfigCommands()"
+		writeFile(servicePackage(project, service, "cli"), "commands.go", configCommandsSource(project, service))
+	}
+
+	greet := `var err error
+			greeting, err = service.Greet(cmd.Context(), args[0])
+			cobra.CheckErr(err)`
+	greetMods := []string{serviceImport(project, service, "internal/service")}
+	if layeredArch() {
+		greet = `result, err := ` + newGreeter(service) + `.Greet(cmd.Context(), args[0])
+			cobra.CheckErr(err)
+			greeting = result.Message`
+		greetMods = greeterImports(project, service)
+	}
+	writeFile(servicePackage(project, service, "cli"), "root.go", goSource("cli",
+		[]string{"fmt"},
+		append([]string{"github.com/spf13/cobra", project + "/shared/shutdown"}, greetMods...),
+		fmt.Sprintf(`var rootCmd = &cobra.Command{
 	Use:   "cli",
 	Short: "CLI entry point",
 	Run: func(cmd *cobra.Command, args []string) {
 		greeting := "👋 Hello from the %s CLI"
 		if len(args) > 0 {
-			greeting = service.Greet(args[0])
+			%s
 		}
 
 		fmt.Println(greeting + "!")
 	},
 }
 
+// Execute runs the command with a context cancelled on SIGINT/SIGTERM,
+// available to commands as cmd.Context()
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	ctx, stop := shutdown.Context()
+	defer stop()%s
+	cobra.CheckErr(rootCmd.ExecuteContext(ctx))
 }
-`, project, service, service))
+`, service, greet, addCommands)))
+
+	writeFile(servicePackage(project, service, "cli"), "version.go", goSource("cli",
+		[]string{"fmt"},
+		[]string{"github.com/spf13/cobra", project + "/shared/version"},
+		`var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print build metadata",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(version.String())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
+`))
+
+	if configInCode() {
+		writeFile(servicePackage(project, service, "config"), "defaults.go", configDefaultsSource(project, service, port))
+	} else if protobufConfig() {
+		writeFile(servicePackage(project, service, "config"), "config.txtpb", configTextproto(project, port))
+	} else {
+		writeFile(servicePackage(project, service, "config"), "config.yaml", configYAML(project, port))
+	}
+
+	if !opts.NoReadme {
+		writeFile(servicePath, "README.md", fmt.Sprintf(`# %s
+%s
+## Ports
 
-	writeFile(filepath.Join(project, "services", service, "config"), "config.yaml", fmt.Sprintf(`server:
-  port: %d
-`, 8080+rand.Intn(10)))
+Service ports are assigned as base port + service index, in the order
+services were added to the project.
 
-	writeFile(filepath.Join(project, "services", service, "db"), "schema.sql", `-- SQL schema placeholder
+| Index | Base port | API port |
+| --- | --- | --- |
+| %d | %d | %d |
+
+The port lives in %s under server.port.%s
+`, service, descriptionLine(), index, opts.BasePort, port, configLocation(), transportPortNote(port)))
+	}
+
+	writeFile(servicePackage(project, service, "db"), "schema.sql", `-- SQL schema placeholder
 CREATE TABLE example (
     id SERIAL PRIMARY KEY,
     name TEXT NOT NULL
 );
 `)
 
-	writeFile(filepath.Join(project, "services", service, "internal", "service"), "service.go", `package service
+	if opts.Sqlc {
+		createSqlc(project, service)
+	}
+
+	if opts.Docker {
+		createDocker(project, service, port)
+	}
+
+	if opts.Compose {
+		createCompose(project, service, port)
+	}
+
+	if opts.Helm {
+		createHelmChart(project, service, port)
+	}
+
+	if opts.Systemd {
+		createSystemd(project, service, port)
+	}
 
-func Greet(name string) string {
-	return "👋 Hello " + name
+	if opts.LoadTest == "k6" {
+		createLoadTest(project, service, port)
+	}
+
+	if opts.Tests {
+		createTests(project, service)
+	}
+
+	if opts.Realtime != "" {
+		createRealtime(project, service, port)
+	}
+
+	if opts.FeatureFlags {
+		writeFile(servicePackage(project, service, "api"), "beta.go", betaHandlerSource(project))
+	}
+
+	if opts.Client {
+		createClient(project, service, port)
+	}
+
+	if usesDatabase() {
+		createDatabase(project, service)
+	}
+
+	if opts.ExampleCRUD {
+		createExampleCRUD(project, service)
+	}
+
+	if opts.SeedData {
+		createSeedData(project, service)
+	}
+
+	if testify() && (opts.Tests || opts.ExampleCRUD) {
+		requireTestify(project, service)
+	}
+
+	if cleanArch() {
+		createCleanArch(project, service)
+	} else if dddArch() {
+		createDDD(project, service)
+	} else {
+		writeFile(servicePackage(project, service, "internal/service"), "service.go", `package service
+
+import "context"
+
+// Greet builds a greeting for name. Service functions take the caller's
+// context first so cancelled requests stop the work they started.
+func Greet(ctx context.Context, name string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "👋 Hello " + name, nil
 }
 `)
+	}
+
+	writeLayoutFiles(layout.service(), servicePath, serviceTemplates, templateData{Project: project, Service: service, Port: port, GoVersion: modGoVersion()})
+
+	// Point the service module at the local shared module
+	if !opts.SingleModule {
+		if err := runCmd(servicePath, "go", "mod", "edit", "-replace", project+"/shared=../../shared"); err != nil {
+			warnf("Failed to run 'go mod edit'")
+		}
+		// shared/config imports the generated config message
+		if protobufConfig() {
+			if err := runCmd(servicePath, "go", "mod", "edit", "-replace", project+"/shared/proto=../../shared/proto"); err != nil {
+				warnf("Failed to run 'go mod edit'")
+			}
+		}
+	}
+	wireDependencies(project, service)
 
-	// Run go mod tidy in service folder
-	servicePath := filepath.Join(project, "services", service)
-	if err := runCmd(servicePath, "go", "mod", "edit", "-replace", project+"/shared=../../shared"); err != nil {
-		log.Println("⚠️ Failed to run 'go mod edit'")
+	switch opts.Transport {
+	case "grpc":
+		createGRPC(project, service)
+	case "graphql":
+		createGraphQL(project, service)
 	}
 
-	if err := runCmd(servicePath, "go", "mod", "tidy"); err != nil {
-		log.Printf("⚠️ Failed to run 'go mod tidy': %v", err)
-	} else {
-		fmt.Println("🧹 go mod tidy run inside", service)
+	if serviceType(service) == "worker" {
+		createWorker(project, service)
+	}
+
+	if opts.Messaging == "nats" {
+		writeFile(servicePackage(project, service, "internal/events"), "events.go", eventsSource(project, service))
+		writeFile(servicePackage(project, service, "cli"), "publish.go", publishCmdSource(project, service))
+	}
+
+	if len(opts.Require) > 0 {
+		requireModules(project, service)
+	}
+}
+
+// finishService wires a scaffolded service into the files shared by the
+// whole project. These are not safe to update concurrently.
+func finishService(project, service string) {
+	// aupdate go.work with the service name and the services it depends on
+	if !opts.SingleModule && !goWorkOff() {
+		for _, svc := range append([]string{service}, dependencies(service)...) {
+			workUse(project, svc)
+		}
+	}
+
+	addMakefileTargets(project, service)
+	if opts.Runner == "just" {
+		createJustfile(project)
+	}
+	addJustfileRecipes(project, service)
+	if opts.Procfile {
+		addProcfileEntry(project, service)
 	}
+	addCodeOwner(project, service)
 
-	// aupdate go.work with the service name
-	if err := runCmd(project, "go", "work", "use", fmt.Sprintf("./services/%s", service)); err != nil {
-		log.Printf("⚠️ Failed to run go work use ./services/%s", service)
+	addReadmeEntry(project, service)
+}
+
+// addReadmeEntry lists the service in the project README
+func addReadmeEntry(project, service string) {
+	readmePath := filepath.Join(project, "README.md")
+	readmeContent := fmt.Sprintf(`- %s (%s)`, serviceRel(service), entrypointsLabel(project, service))
+	if !fileContainsText(readmePath, readmeContent) {
+		appendContent(readmePath, readmeContent+"\n")
+	}
+}
+
+// workUse adds the service module to the go.work of the project or of its
+// --workspace-root; go work use leaves modules already listed alone. A
+// staged run leaves the --workspace-root go.work to its parent.
+func workUse(project, service string) {
+	if deferWorkspace() {
+		return
 	}
+	workspace := workspaceDir(project)
+	rel, err := filepath.Rel(absPath(workspace), absPath(serviceDir(project, service)))
+	if err != nil {
+		rel = serviceDir(project, service)
+	}
+	use := "./" + filepath.ToSlash(rel)
+	if err := runCmd(workspace, "go", "work", "use", use); err != nil {
+		warnf("Failed to run go work use %s in %s", use, workspace)
+	}
+}
+
+// descriptionLine renders --description as a README paragraph, or nothing
+func descriptionLine() string {
+	if opts.Description == "" {
+		return ""
+	}
+	return "\n" + opts.Description + "\n"
+}
 
+// descriptionComment renders --description as a go.mod comment, or nothing
+func descriptionComment() string {
+	if opts.Description == "" {
+		return ""
+	}
+	return "// " + opts.Description + "\n"
+}
+
+// addMakefileTargets appends the run targets of a service unless present
+func addMakefileTargets(project, service string) {
 	makefilePath := filepath.Join(project, "Makefile")
 	if !fileContainsText(makefilePath, fmt.Sprintf("run-%s-api", service)) {
-		makefileContent := fmt.Sprintf(`run-%s-api:
-	go run services/%s/cmd/api/main.go
+		makefileContent := fmt.Sprintf(`run-%[1]s-api: ## Run the %[1]s API
+	%[2]s
 
-run-%s-cli:
-	go run services/%s/cmd/cli/main.go
+run-%[1]s-cli: ## Run the %[1]s CLI
+	%[3]s
 
-`, service, service, service, service)
+`, service, goRunCmd(service, "api"), goRunCmd(service, "cli"))
 		appendContent(makefilePath, makefileContent)
 	}
+	if hasWorker(project, service) && !fileContainsText(makefilePath, fmt.Sprintf("run-%s-worker", service)) {
+		appendContent(makefilePath, fmt.Sprintf(`run-%[1]s-worker: ## Run the %[1]s worker
+	%[2]s
 
-	// Update README.md
-	readmePath := filepath.Join(project, "README.md")
-	readmeContent := fmt.Sprintf(`- services/%s (API, CLI)`, service)
-	if !fileContainsText(readmePath, readmeContent) {
-		appendContent(readmePath, readmeContent)
+`, service, goRunCmd(service, "worker")))
 	}
 }
 
+// createSqlc adds sqlc configuration and sample queries for the service.
+// The engine is postgresql to match the SERIAL column in db/schema.sql.
+func createSqlc(project, service string) {
+	servicePath := serviceDir(project, service)
+
+	writeFile(servicePath, "sqlc.yaml", `version: "2"
+sql:
+  - engine: "postgresql"
+    schema: "db/schema.sql"
+    queries: "db/queries.sql"
+    gen:
+      go:
+        package: "db"
+        out: "db"
+`)
+
+	writeFile(filepath.Join(servicePath, "db"), "queries.sql", `-- name: GetExample :one
+SELECT id, name FROM example
+WHERE id = $1;
+
+-- name: ListExamples :many
+SELECT id, name FROM example
+ORDER BY id;
+
+-- name: CreateExample :one
+INSERT INTO example (name)
+VALUES ($1)
+RETURNING id, name;
+`)
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, "sqlc:") {
+		appendContent(makefilePath, `sqlc: ## Generate Go code from the sqlc queries
+	@for cfg in services/*/sqlc.yaml internal/*/sqlc.yaml; do \
+		[ -f "$$cfg" ] || continue; \
+		echo "sqlc generate -f $$cfg"; \
+		sqlc generate -f $$cfg || exit 1; \
+	done
+
+`)
+	}
+}
+
+// transportPortNote documents the gRPC or GraphQL port in the service README
+func transportPortNote(port int) string {
+	switch opts.Transport {
+	case "grpc":
+		note := fmt.Sprintf("\nThe gRPC server listens on API port + 1000 (%d), under server.grpcPort.", port+1000)
+		if opts.GRPCGateway {
+			note += "\nThe API serves the REST mappings of the .proto under /v1/, proxied to it."
+		}
+		return note
+	case "graphql":
+		return fmt.Sprintf("\nThe GraphQL server listens on API port + 1000 (%d), under server.graphqlPort, with the playground at /.", port+1000)
+	}
+	return ""
+}
+
+// addProcfileEntry adds the service API to the Procfile unless present
+func addProcfileEntry(project, service string) {
+	procfilePath := filepath.Join(project, "Procfile")
+	entry := fmt.Sprintf("%s-api: %s\n", service, goRunCmd(service, "api"))
+	// Match whole entries: user must not be found in superuser-api
+	existing := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(service) + `-api:`)
+	if data, err := os.ReadFile(procfilePath); err == nil && existing.Match(data) {
+		return
+	}
+	appendContent(procfilePath, entry)
+}
+
+// optionalFiles are only appended to when they exist, so projects created
+// with --no-makefile or --no-readme never get them back
+var optionalFiles = map[string]bool{"Makefile": true, "README.md": true}
+
 func appendContent(filePath, content string) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) && optionalFiles[filepath.Base(filePath)] {
+		debugf("skip %s (absent)", filePath)
+		return
+	}
 	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
 		log.Fatalf("Error opening file %s: %v", filePath, err)
@@ -418,6 +1644,7 @@ func appendContent(filePath, content string) {
 
 	// Append the new content
 	newContent := string(existingContent) + content
+	debugf("append %s (+%d bytes)", filePath, len(content))
 	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
 		log.Fatalf("Error writing to file %s: %v", filePath, err)
 	}
@@ -425,6 +1652,9 @@ func appendContent(filePath, content string) {
 
 func fileContainsText(filepath, text string) bool {
 	content, err := os.ReadFile(filepath)
+	if os.IsNotExist(err) {
+		return false
+	}
 	if err != nil {
 		log.Fatalf("Error reading %s file: %v", filepath, err)
 	}
@@ -437,5 +1667,5 @@ func fileContainsText(filepath, text string) bool {
 func formatCode(path string) error {
 	cmd := exec.Command("go", "fmt", "./...")
 	cmd.Dir = path
-	return cmd.Run()
+	return runTraced(cmd)
 }
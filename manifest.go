@@ -0,0 +1,261 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// layoutManifest is the --layout-file format. Directories replace the
+// built-in empty-directory lists; files are written after the built-in ones
+// and may overwrite them.
+//
+//	templateDir: ./templates
+//	project:
+//	  dirs: [shared/appctx, shared/config, shared/middleware, shared/version, deploy, docs]
+//	  files:
+//	    - path: docs/ARCHITECTURE.md
+//	      template: architecture.md.tmpl
+//	service:
+//	  dirs: [api, cli, config, db, internal/service, cmd/api, cmd/cli]
+//	  files:
+//	    - path: api/greet.go
+//	      template: greet.go
+type layoutManifest struct {
+	TemplateDir string        `yaml:"templateDir"`
+	Project     layoutSection `yaml:"project"`
+	Service     layoutSection `yaml:"service"`
+}
+
+type layoutSection struct {
+	Dirs  []string     `yaml:"dirs"`
+	Files []layoutFile `yaml:"files"`
+}
+
+type layoutFile struct {
+	Path     string `yaml:"path"`
+	Template string `yaml:"template"`
+}
+
+// templateData is available to templates loaded from the template dir
+type templateData struct {
+	Project   string
+	Service   string
+	Port      int
+	GoVersion string
+}
+
+// projectTemplates are the built-in templates usable in the project section
+var projectTemplates = map[string]func(templateData) string{
+	"config.go":     func(d templateData) string { return configSource(d.Project) },
+	"middleware.go": func(d templateData) string { return middlewareSource(d.Project) },
+	"version.go":    func(templateData) string { return versionSource },
+	"gitignore":     func(templateData) string { return gitignoreContent() },
+}
+
+// serviceTemplates are the built-in templates usable in the service section
+var serviceTemplates = map[string]func(templateData) string{
+	"api-main.go": func(d templateData) string { return apiMainSource(d.Project, d.Service) },
+	"greet.go":    func(d templateData) string { return greetSource(d.Project, d.Service) },
+	"config.yaml": func(d templateData) string { return configYAML(d.Project, d.Port) },
+}
+
+// layout is the loaded --layout-file, nil when the built-in layout is used
+var layout *layoutManifest
+
+// project returns the project section, empty without a manifest
+func (m *layoutManifest) project() layoutSection {
+	if m == nil {
+		return layoutSection{}
+	}
+	return m.Project
+}
+
+// service returns the service section, empty without a manifest
+func (m *layoutManifest) service() layoutSection {
+	if m == nil {
+		return layoutSection{}
+	}
+	return m.Service
+}
+
+// loadLayout reads and validates a layout manifest. A relative templateDir
+// is resolved against the manifest's directory.
+func loadLayout(path string) *layoutManifest {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("❌ Error reading layout file: %v", err)
+	}
+
+	var manifest layoutManifest
+	if err := yaml.UnmarshalStrict(data, &manifest); err != nil {
+		log.Fatalf("❌ Invalid layout file %s: %v", path, err)
+	}
+	if manifest.TemplateDir != "" && !filepath.IsAbs(manifest.TemplateDir) {
+		manifest.TemplateDir = filepath.Join(filepath.Dir(path), manifest.TemplateDir)
+	}
+
+	sections := []struct {
+		name      string
+		section   layoutSection
+		templates map[string]func(templateData) string
+	}{
+		{"project", manifest.Project, projectTemplates},
+		{"service", manifest.Service, serviceTemplates},
+	}
+	for _, s := range sections {
+		for _, dir := range s.section.Dirs {
+			checkLayoutPath(s.name, dir)
+		}
+		for _, file := range s.section.Files {
+			checkLayoutPath(s.name, file.Path)
+			if _, ok := s.templates[file.Template]; ok {
+				continue
+			}
+			if manifest.TemplateDir == "" {
+				log.Fatalf("❌ Unknown %s template %q in %s, and no templateDir is set.", s.name, file.Template, path)
+			}
+			if _, err := os.Stat(filepath.Join(manifest.TemplateDir, file.Template)); err != nil {
+				log.Fatalf("❌ Unknown %s template %q in %s: %v", s.name, file.Template, path, err)
+			}
+		}
+	}
+	return &manifest
+}
+
+// checkLayoutPath rejects manifest paths escaping the directory they are
+// relative to
+func checkLayoutPath(section, path string) {
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(filepath.Clean(path), "..") {
+		log.Fatalf("❌ Invalid %s path %q in layout file, expected a relative path.", section, path)
+	}
+}
+
+// layoutDirs returns the manifest directories of a section joined to base,
+// or defaults when no manifest is loaded
+func layoutDirs(section layoutSection, base string, defaults []string) []string {
+	if layout == nil {
+		return defaults
+	}
+	dirs := make([]string, 0, len(section.Dirs))
+	for _, dir := range section.Dirs {
+		dirs = append(dirs, filepath.Join(base, dir))
+	}
+	return dirs
+}
+
+// overridingTemplate returns the file of templateDir replacing a built-in
+// template, or "" when the built-in one is used
+func overridingTemplate(templateDir, name string) string {
+	if templateDir == "" {
+		return ""
+	}
+	path := filepath.Join(templateDir, name)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// writeLayoutFiles renders the manifest files of a section under base. A
+// file of the template dir named after a built-in template overrides it.
+func writeLayoutFiles(section layoutSection, base string, templates map[string]func(templateData) string, data templateData) {
+	for _, file := range section.Files {
+		content := ""
+		if builtin, ok := templates[file.Template]; ok && overridingTemplate(layout.TemplateDir, file.Template) == "" {
+			content = builtin(data)
+		} else {
+			content = renderLayoutTemplate(filepath.Join(layout.TemplateDir, file.Template), data)
+		}
+
+		writeFile(base, file.Path, content)
+	}
+}
+
+// renderLayoutTemplate executes a text/template file from the template dir
+func renderLayoutTemplate(path string, data templateData) string {
+	debugf("render template %s", path)
+	tpl, err := template.ParseFiles(path)
+	if err != nil {
+		log.Fatalf("❌ Error parsing template %s: %v", path, err)
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		log.Fatalf("❌ Error rendering template %s: %v", path, err)
+	}
+	if strings.HasSuffix(path, ".go") || strings.HasSuffix(path, ".go.tmpl") {
+		return formatGo(b.String())
+	}
+	return b.String()
+}
+
+// listTemplates prints the built-in templates of each layout section and,
+// given a template dir directly or through a layout file, which of them it
+// overrides and the custom templates it adds
+func listTemplates(args []string) {
+	flags := flag.NewFlagSet("list-templates", flag.ExitOnError)
+	templateDir := flags.String("template-dir", "", "Directory of layout templates to compare with the built-in ones")
+	layoutFile := flags.String("layout-file", "", "Layout file whose templateDir to compare with the built-in ones")
+	flags.Parse(args)
+	if *templateDir == "" && *layoutFile != "" {
+		*templateDir = loadLayout(*layoutFile).TemplateDir
+	}
+	if *templateDir != "" {
+		if info, err := os.Stat(*templateDir); err != nil || !info.IsDir() {
+			log.Fatalf("❌ Template dir %s is not a directory.", *templateDir)
+		}
+	}
+
+	builtin := map[string]bool{}
+	for _, s := range []struct {
+		name      string
+		templates map[string]func(templateData) string
+	}{
+		{"project", projectTemplates},
+		{"service", serviceTemplates},
+	} {
+		fmt.Printf("Built-in %s templates:\n", s.name)
+		for _, name := range slices.Sorted(maps.Keys(s.templates)) {
+			builtin[name] = true
+			if path := overridingTemplate(*templateDir, name); path != "" {
+				fmt.Printf("  %-16s overridden by %s\n", name, path)
+			} else {
+				fmt.Printf("  %s\n", name)
+			}
+		}
+	}
+	if *templateDir == "" {
+		return
+	}
+
+	var custom []string
+	err := filepath.WalkDir(*templateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(*templateDir, path)
+		if err == nil && !builtin[filepath.ToSlash(rel)] {
+			custom = append(custom, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to list %s: %v", *templateDir, err)
+	}
+	fmt.Printf("Custom templates in %s:\n", *templateDir)
+	if len(custom) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, name := range custom {
+		fmt.Printf("  %s\n", name)
+	}
+}
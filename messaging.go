@@ -0,0 +1,127 @@
+package main
+
+import "fmt"
+
+// messagingSource renders shared/messaging/messaging.go, the NATS client
+// wrapper generated with --messaging nats
+func messagingSource() string {
+	return goSource("messaging",
+		[]string{"encoding/json"},
+		[]string{"github.com/nats-io/nats.go"},
+		`// Client publishes and subscribes to NATS subjects
+type Client struct {
+	conn *nats.Conn
+}
+
+// Connect dials the NATS server at url. Once connected the client keeps
+// reconnecting in the background if the server goes away.
+func Connect(url, name string) (*Client, error) {
+	conn, err := nats.Connect(url, nats.Name(name), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Publish sends data on subject
+func (c *Client) Publish(subject string, data []byte) error {
+	return c.conn.Publish(subject, data)
+}
+
+// PublishJSON sends v encoded as JSON on subject
+func (c *Client) PublishJSON(subject string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Publish(subject, data)
+}
+
+// Subscribe calls handle with every message published on subject
+func (c *Client) Subscribe(subject string, handle func(data []byte)) error {
+	_, err := c.conn.Subscribe(subject, func(msg *nats.Msg) {
+		handle(msg.Data)
+	})
+	return err
+}
+
+// Close flushes pending messages, unsubscribes and disconnects
+func (c *Client) Close() error {
+	return c.conn.Drain()
+}
+`)
+}
+
+// eventsSource renders internal/events/events.go, the sample event a
+// service's CLI publishes and its API consumes
+func eventsSource(project, service string) string {
+	return goSource("events",
+		[]string{"encoding/json", "log"},
+		[]string{project + "/shared/messaging"},
+		renderTemplate(fmt.Sprintf(`// GreetedSubject carries Greeted events
+const GreetedSubject = "%[1]s.greeted"
+
+// Greeted is published when someone is greeted
+type Greeted struct {
+	Name string §json:"name"§
+}
+
+// PublishGreeted publishes a Greeted event for name
+func PublishGreeted(client *messaging.Client, name string) error {
+	return client.PublishJSON(GreetedSubject, Greeted{Name: name})
+}
+
+// ConsumeGreeted logs every Greeted event
+func ConsumeGreeted(client *messaging.Client) error {
+	return client.Subscribe(GreetedSubject, func(data []byte) {
+		var event Greeted
+		if err := json.Unmarshal(data, &event); err != nil {
+			log.Printf("⚠️ Invalid %%s event: %%v", GreetedSubject, err)
+			return
+		}
+		log.Printf("📨 %%s greeted", event.Name)
+	})
+}
+`, service), '§'))
+}
+
+// publishCmdSource renders cli/publish.go, the sample publisher
+func publishCmdSource(project, service string) string {
+	load, loadMods := loadConfigCall(project, service)
+	return goSource("cli",
+		[]string{"fmt"},
+		append([]string{
+			"github.com/spf13/cobra",
+			project + "/shared/config",
+			project + "/shared/messaging",
+			serviceImport(project, service, "internal/events"),
+		}, loadMods...),
+		fmt.Sprintf(`var publishCmd = &cobra.Command{
+	Use:   "publish <name>",
+	Short: "Publish a Greeted event",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url := "nats://localhost:4222"
+		if config, err := %[2]s; err == nil && config.Messaging.URL != "" {
+			url = config.Messaging.URL
+		}
+
+		client, err := messaging.Connect(url, "%[1]s-cli")
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if err := events.PublishGreeted(client, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("📤 Published %%s\n", events.GreetedSubject)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+}
+`, service, load))
+}
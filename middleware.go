@@ -0,0 +1,171 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// traceIDHeaderPattern matches the header names --trace-id-header accepts,
+// the token characters of RFC 9110
+var traceIDHeaderPattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// newRequestIDSource returns newRequestID of the middleware. A generated
+// traceparent header is a valid W3C trace context, other headers get a
+// random hex ID.
+func newRequestIDSource() string {
+	if strings.EqualFold(opts.TraceIDHeader, "traceparent") {
+		return `func newRequestID() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return "00-" + hex.EncodeToString(b[:16]) + "-" + hex.EncodeToString(b[16:]) + "-01"
+}
+`
+	}
+	return `func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+`
+}
+
+// middlewareSource renders shared/middleware/middleware.go
+func middlewareSource(project string) string {
+	std := []string{"crypto/rand", "encoding/hex", "log/slog", "net/http", "runtime/debug", "time"}
+	logLine := `log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))`
+	panicLine := `log.Printf("panic: %v\n%s", err, debug.Stack())`
+	mods := []string{project + "/shared/appctx"}
+	extra := ""
+
+	if opts.StructuredLogging {
+		// The request logger already carries the request ID
+		logLine = `appctx.Logger(r.Context()).InfoContext(r.Context(), "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start),
+		)`
+		panicLine = `slog.ErrorContext(r.Context(), "panic",
+					"request_id", r.Header.Get(RequestIDHeader),
+					"error", err,
+					"stack", string(debug.Stack()),
+				)`
+	} else {
+		std = append(std, "log")
+	}
+
+	if opts.TimeoutMiddleware {
+		extra += `
+// Timeout cancels the request context after d and answers 503 when the
+// handler has not responded by then. A zero d disables it.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.TimeoutHandler(next, d, "request timed out")
+	}
+}
+`
+	}
+
+	if opts.RateLimit {
+		mods = append(mods, "golang.org/x/time/rate")
+		extra += `
+// RateLimit rejects requests beyond rps, allowing bursts of up to burst
+// requests, with a 429. One token bucket is shared by all clients.
+func RateLimit(rps float64, burst int) Middleware {
+	limiter := rate.NewLimiter(rate.Limit(rps), burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Allow() {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+`
+	}
+
+	return goSource("middleware", std, mods, `// RequestIDHeader carries the request ID, set with --trace-id-header
+const RequestIDHeader = `+strconv.Quote(opts.TraceIDHeader)+`
+
+// Middleware wraps a handler with behavior shared by its routes
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with mws, the first outermost: Chain(h, a, b) passes
+// requests through a, then b, then h. Order matters, list them as they
+// should see the request.
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// statusRecorder captures the status code written by the wrapped handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController flush or hijack the wrapped writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Logging logs method, path, status and duration of every request
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		`+logLine+`
+	})
+}
+
+// Recovery turns a panic in a handler into a 500 response and logs the stack trace
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				`+panicLine+`
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestID makes sure every request carries a `+opts.TraceIDHeader+` header and
+// echoes it back in the response. The ID and a logger tagged with it are
+// stored in the request context, see appctx.RequestID and appctx.Logger.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := appctx.WithRequestID(r.Context(), id)
+		ctx = appctx.WithLogger(ctx, slog.Default().With("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+`+newRequestIDSource()+extra)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// goImportMeta matches the <meta name="go-import" content="prefix vcs repo">
+// tag the go command reads to find the repository of an import path
+var goImportMeta = regexp.MustCompile(`<meta\s+name="go-import"\s+content="([^"]+)"`)
+
+// checkModule implements --check-module: it warns when the module path, the
+// project name, cannot be imported by other modules or when its repository
+// does not answer
+func checkModule(path string) {
+	if err := checkModulePath(path); err != nil {
+		warnf("Module path %q: %v", path, err)
+		return
+	}
+
+	repo, err := moduleRepository(path)
+	if err != nil {
+		warnf("Module path %q is not reachable: %v", path, err)
+		return
+	}
+	fmt.Println("🔗 Module path", path, "resolves to", repo)
+}
+
+// checkModulePath reports whether path looks like a module others can
+// import: host/path elements, the host holding a dot
+func checkModulePath(path string) error {
+	elems := strings.Split(path, "/")
+	for _, elem := range elems {
+		if elem == "" || elem == "." || elem == ".." {
+			return fmt.Errorf("empty or relative path element")
+		}
+		if strings.Trim(elem, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-._~") != "" {
+			return fmt.Errorf("element %q has characters other than letters, digits and -._~", elem)
+		}
+	}
+	host := elems[0]
+	if !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return fmt.Errorf("%q is not a host, so the module is only importable inside this project; did you mean github.com/<owner>/%s?", host, path)
+	}
+	if host != strings.ToLower(host) {
+		return fmt.Errorf("the host %q must be lower case", host)
+	}
+	if len(elems) < 2 {
+		return fmt.Errorf("expected a repository path after the host %q", host)
+	}
+	return nil
+}
+
+// moduleRepository resolves path the way go get does, from the go-import
+// meta tag served at https://<path>?go-get=1, and checks that a git
+// repository answers at the address it names
+func moduleRepository(path string) (string, error) {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get("https://" + path + "?go-get=1")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	for _, match := range goImportMeta.FindAllStringSubmatch(string(body), -1) {
+		fields := strings.Fields(match[1])
+		if len(fields) != 3 || (path != fields[0] && !strings.HasPrefix(path, fields[0]+"/")) {
+			continue
+		}
+		if fields[1] != "git" {
+			return fields[2], nil
+		}
+		cmd := exec.Command("git", "ls-remote", "--heads", fields[2])
+		// Fail rather than ask for credentials of a private or missing repository
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if err := runTraced(cmd); err != nil {
+			return "", fmt.Errorf("no git repository answers at %s (create it before publishing)", fields[2])
+		}
+		return fields[2], nil
+	}
+	return "", fmt.Errorf("https://%s?go-get=1 answered %s without a go-import meta tag", path, resp.Status)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// createFlake writes flake.nix, generated with --nix. The Go toolchain is
+// pinned to the version detected at scaffold time and services are
+// discovered from the tree, so adding a service needs no flake edits.
+func createFlake(project string) {
+	tools := []string{"go", "pkgs.git"}
+	if opts.CI != "" {
+		tools = append(tools, "pkgs.golangci-lint")
+	}
+	if opts.Sqlc {
+		tools = append(tools, "pkgs.sqlc")
+	}
+	if opts.Transport == "grpc" {
+		tools = append(tools, "pkgs.buf", "pkgs.protoc-gen-go", "pkgs.protoc-gen-go-grpc")
+	}
+	if opts.Runner == "just" {
+		tools = append(tools, "pkgs.just")
+	}
+
+	packages := `
+        # Each service under services/ is its own module, built with the
+        # workspace disabled so shared resolves through its replace directive
+        service = name: buildGoModule {
+          pname = name;
+          version = self.shortRev or "dev";
+          src = ./.;
+          modRoot = "services/${name}";
+          subPackages = [ "cmd/api" "cmd/cli" ];
+          env.GOWORK = "off";
+          vendorHash = vendorHashes.${name} or pkgs.lib.fakeHash;
+        };
+        services = builtins.attrNames (builtins.readDir ./services);
+      in
+      {
+        packages = pkgs.lib.genAttrs services service;`
+	if opts.SingleModule {
+		packages = `      in
+      {
+        packages.default = buildGoModule {
+          pname = "%[1]s";
+          version = self.shortRev or "dev";
+          src = ./.;
+          subPackages = map (cmd: "cmd/${cmd}") (builtins.attrNames (builtins.readDir ./cmd));
+          vendorHash = vendorHashes.default or pkgs.lib.fakeHash;
+        };`
+	}
+
+	writeFile(project, "flake.nix", fmt.Sprintf(`{
+  description = "%[1]s";
+
+  inputs = {
+    nixpkgs.url = "github:NixOS/nixpkgs/nixos-unstable";
+    flake-utils.url = "github:numtide/flake-utils";
+  };
+
+  outputs = { self, nixpkgs, flake-utils }:
+    flake-utils.lib.eachDefaultSystem (system:
+      let
+        pkgs = nixpkgs.legacyPackages.${system};
+        go = pkgs.go_%[2]s;
+        buildGoModule = pkgs.buildGoModule.override { inherit go; };
+
+        # Fill in the hashes reported by the first nix build
+        vendorHashes = { };
+`+packages+`
+
+        devShells.default = pkgs.mkShell {
+          packages = [ %[3]s ];
+        };
+      });
+}
+`, project, strings.ReplaceAll(goVer, ".", "_"), strings.Join(tools, " ")))
+}
@@ -0,0 +1,117 @@
+package main
+
+import "fmt"
+
+// paginationSource is shared/pagination/pagination.go, the limit/offset
+// convention shared by every service's list endpoints
+var paginationSource = renderTemplate(`// Package pagination parses limit/offset query parameters and wraps list
+// responses in a common envelope
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Params selects a page of a list
+type Params struct {
+	Limit  int
+	Offset int
+}
+
+// FromRequest reads ?limit= and ?offset=. A missing limit defaults to
+// DefaultLimit and larger ones are capped at MaxLimit; malformed or
+// negative values are errors the caller should answer with a 400.
+func FromRequest(r *http.Request) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+	query := r.URL.Query()
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return Params{}, fmt.Errorf("limit must be a positive integer, got %q", raw)
+		}
+		p.Limit = min(limit, MaxLimit)
+	}
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return Params{}, fmt.Errorf("offset must be a non-negative integer, got %q", raw)
+		}
+		p.Offset = offset
+	}
+	return p, nil
+}
+
+// Page is the JSON envelope of a paginated list. Next is the offset of the
+// following page, omitted on the last one.
+type Page[T any] struct {
+	Items  []T  §json:"items"§
+	Total  int  §json:"total"§
+	Limit  int  §json:"limit"§
+	Offset int  §json:"offset"§
+	Next   *int §json:"next,omitempty"§
+}
+
+// NewPage wraps the items of the page selected by p out of total
+func NewPage[T any](items []T, total int, p Params) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	page := Page[T]{Items: items, Total: total, Limit: p.Limit, Offset: p.Offset}
+	if next := p.Offset + p.Limit; next < total {
+		page.Next = &next
+	}
+	return page
+}
+
+// Slice returns the page of an in-memory list selected by p
+func Slice[T any](all []T, p Params) []T {
+	if p.Offset >= len(all) {
+		return nil
+	}
+	return all[p.Offset:min(p.Offset+p.Limit, len(all))]
+}
+`, '§')
+
+// itemsSource renders api/items.go, a sample paginated list handler, in the
+// package of the sample handlers
+func itemsSource(project string) string {
+	return goSource(handlersPkg(),
+		[]string{"encoding/json", "fmt", "net/http"},
+		[]string{project + "/shared/apierror", project + "/shared/pagination"},
+		renderTemplate(fmt.Sprintf(`// Item is the sample resource listed by ListItemsHandler
+type Item struct {
+	ID   int    §json:"id"§
+	Name string §json:"name"§
+}
+
+var sampleItems = func() []Item {
+	items := make([]Item, %d)
+	for i := range items {
+		items[i] = Item{ID: i + 1, Name: fmt.Sprintf("item %%d", i+1)}
+	}
+	return items
+}()
+
+// ListItemsHandler serves sampleItems a page at a time, e.g.
+// %[2]s/items?limit=10&offset=20
+func ListItemsHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := pagination.FromRequest(r)
+	if err != nil {
+		apierror.WriteError(w, apierror.New(apierror.InvalidRequest, err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	page := pagination.NewPage(pagination.Slice(sampleItems, params), len(sampleItems), params)
+	json.NewEncoder(w).Encode(page)
+}
+`, 42, routePrefix()), '§'))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// rcName is the tool's settings file, read from the working directory and
+// then the home directory:
+//
+//	plugins: ./generators
+//	profiles:
+//	  internal-api:
+//	    transport: http
+//	    health: true
+type rcFile struct {
+	Plugins  string                       `yaml:"plugins"`
+	Profiles map[string]map[string]string `yaml:"profiles"`
+}
+
+const rcName = ".creategorc"
+
+// loadRC returns the first .creategorc found, with a relative plugins
+// directory resolved against the file's directory, or an empty one
+func loadRC() rcFile {
+	candidates := []string{rcName}
+	if from := os.Getenv(atomicEnv); from != "" {
+		// The child run of --atomic works in a temporary directory
+		candidates = []string{filepath.Join(from, rcName)}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, rcName))
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			log.Fatalf("❌ Error reading %s: %v", path, err)
+		}
+
+		var rc rcFile
+		if err := yaml.UnmarshalStrict(data, &rc); err != nil {
+			log.Fatalf("❌ Invalid %s: %v", path, err)
+		}
+		if rc.Plugins != "" && !filepath.IsAbs(rc.Plugins) {
+			rc.Plugins = filepath.Join(filepath.Dir(path), rc.Plugins)
+		}
+		debugf("loaded %s", path)
+		return rc
+	}
+	return rcFile{}
+}
+
+// runPlugins runs every executable in the plugins directory, in name order,
+// once per new service with the project, service and module directory as
+// arguments. A failing plugin is a warning, fatal with --strict.
+func runPlugins(project string, services []string) {
+	dir := loadRC().Plugins
+	if dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		warnf("Failed to read the plugins directory %s: %v", dir, err)
+		return
+	}
+
+	var plugins []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			debugf("skip plugin %s: not an executable file", entry.Name())
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			warnf("Failed to resolve plugin %s: %v", entry.Name(), err)
+			continue
+		}
+		plugins = append(plugins, path)
+	}
+	sort.Strings(plugins)
+
+	for _, service := range services {
+		for _, plugin := range plugins {
+			if err := runCmd(".", plugin, project, service, moduleDir(project, service)); err != nil {
+				warnf("Plugin %s failed for %s: %v", filepath.Base(plugin), service, err)
+				continue
+			}
+			log.Printf("🧩 Plugin %s ran for %s", filepath.Base(plugin), service)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// configuredPort matches server.port in a config.yaml or config.txtpb, or
+// its assignment in a --config code defaults.go
+var configuredPort = regexp.MustCompile(`(?m)^server(?::| \{)\s*\n(?:[ \t].*\n)*?[ \t]+port:\s*(\d+)|c\.Server\.Port = (\d+)`)
+
+// portOffsets are the distances from the API port of the other ports a
+// service listens on: gRPC or GraphQL, and pprof
+var portOffsets = []int{0, 1000, 2000}
+
+// servicePort returns the API port a service is configured with, or
+// fallback when its configuration cannot be read
+func servicePort(project, service string, fallback int) int {
+	path := filepath.Join(servicePackage(project, service, "config"), configFile())
+	if configInCode() {
+		path = filepath.Join(servicePackage(project, service, "config"), "defaults.go")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fallback
+	}
+	m := configuredPort.FindStringSubmatch(string(data))
+	if m == nil {
+		return fallback
+	}
+	port, err := strconv.Atoi(m[1] + m[2])
+	if err != nil {
+		return fallback
+	}
+	return port
+}
+
+// assignPort returns the API port of a service: the configured one when it
+// exists already, so re-runs keep it, else base + index or the next port
+// no other service is configured with
+func assignPort(project, service string) int {
+	if port := servicePort(project, service, 0); port != 0 {
+		return port
+	}
+	used := map[int]bool{}
+	index := 0
+	for _, existing := range listServices(project) {
+		if existing != service {
+			used[servicePort(project, existing, 0)] = true
+			index++
+		}
+	}
+	port := opts.BasePort + index
+	for used[port] {
+		port++
+	}
+	return port
+}
+
+// portMention is a file mentioning a service's ports, on the lines match
+// selects. Within a compose file only the service's own block is changed.
+type portMention struct {
+	path  string
+	match *regexp.Regexp
+}
+
+// portMentions lists where the tool writes the ports of a service
+func portMentions(project, service string) []portMention {
+	dir := serviceDir(project, service)
+	config := servicePackage(project, service, "config")
+	return []portMention{
+		{filepath.Join(config, "config.yaml"), regexp.MustCompile(`(?m)^[ \t]+(?:port|grpcPort|graphqlPort):[ \t]*\d+`)},
+		{filepath.Join(config, "config.txtpb"), regexp.MustCompile(`(?m)^[ \t]+(?:port|grpc_port|graphql_port):[ \t]*\d+`)},
+		{filepath.Join(config, "defaults.go"), regexp.MustCompile(`c\.(?:Server\.(?:Port|GRPCPort|GraphQLPort)|Pprof\.Port) = \d+`)},
+		{filepath.Join(dir, "Dockerfile"), regexp.MustCompile(`(?m)^EXPOSE \d+`)},
+		{filepath.Join(dir, "README.md"), regexp.MustCompile(`API port \+ 1000 \(\d+\)`)},
+		{filepath.Join(servicePackage(project, service, "client"), "client.go"), regexp.MustCompile(`localhost:\d+`)},
+		{filepath.Join(project, "deploy", service+".service"), regexp.MustCompile(`(?m)^Environment=\w+=\d+`)},
+		{filepath.Join(project, "loadtest", service+".js"), regexp.MustCompile(`localhost:\d+|port\[1\] : \d+`)},
+		{filepath.Join(project, "docker-compose.yml"), regexp.MustCompile(`(?m)^[ \t]+- "\d+:\d+"`)},
+		{filepath.Join(chartDir(project), "values.yaml"), regexp.MustCompile(`(?m)^  ` + regexp.QuoteMeta(service) + `:\n(?:    .*\n)*?    port: \d+`)},
+	}
+}
+
+// resetPorts reassigns base + index to every existing service, in name
+// order, and rewrites the files mentioning their ports
+func resetPorts(project string) {
+	number := regexp.MustCompile(`\d+`)
+	readmeRow := regexp.MustCompile(`(?m)^\| -?\d+ \| \d+ \| \d+ \|$`)
+	dockerfile := func(service string) string {
+		return fmt.Sprintf("dockerfile: %s/Dockerfile", filepath.ToSlash(serviceRel(service)))
+	}
+
+	for i, service := range listServices(project) {
+		old, port := servicePort(project, service, 0), opts.BasePort+i
+		if old == 0 {
+			warnf("No server.port found for %s; its port was not reset", service)
+			continue
+		}
+		if old == port {
+			continue
+		}
+		remap := func(text string) string {
+			return number.ReplaceAllStringFunc(text, func(n string) string {
+				value, _ := strconv.Atoi(n)
+				for _, offset := range portOffsets {
+					if value == old+offset {
+						return strconv.Itoa(port + offset)
+					}
+				}
+				return n
+			})
+		}
+
+		for _, mention := range portMentions(project, service) {
+			data, err := os.ReadFile(mention.path)
+			if err != nil {
+				continue
+			}
+			content := string(data)
+			if filepath.Base(mention.path) == "docker-compose.yml" {
+				blocks := strings.SplitAfter(content, "\n\n")
+				for j, block := range blocks {
+					if strings.Contains(block, dockerfile(service)) {
+						blocks[j] = mention.match.ReplaceAllStringFunc(block, remap)
+					}
+				}
+				content = strings.Join(blocks, "")
+			} else {
+				content = mention.match.ReplaceAllStringFunc(content, remap)
+			}
+			if filepath.Base(mention.path) == "README.md" {
+				content = readmeRow.ReplaceAllString(content, fmt.Sprintf("| %d | %d | %d |", i, opts.BasePort, port))
+			}
+			if content != string(data) {
+				rewriteFile(filepath.Dir(mention.path), filepath.Base(mention.path), content)
+			}
+		}
+		fmt.Printf("🔌 %s: port %d → %d\n", serviceRel(service), old, port)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// builtinProfiles are the --profile option sets available without a
+// .creategorc, as flag name to value
+var builtinProfiles = map[string]map[string]string{
+	"rest-postgres": {
+		"transport":          "http",
+		"example-crud":       "true",
+		"compose":            "true",
+		"health":             "true",
+		"structured-logging": "true",
+		"ratelimit":          "true",
+		"timeout-middleware": "true",
+		"tests":              "true",
+		"ci":                 "gitlab",
+	},
+	"grpc-minimal": {
+		"transport": "grpc",
+	},
+}
+
+// profiles returns the built-in profiles and those of .creategorc, which
+// replace a built-in profile of the same name
+func profiles() map[string]map[string]string {
+	all := maps.Clone(builtinProfiles)
+	maps.Copy(all, loadRC().Profiles)
+	return all
+}
+
+// applyProfile sets the flags of the named profile that were not given
+// explicitly, so the command line overrides the profile
+func applyProfile(name string) {
+	all := profiles()
+	profile, ok := all[name]
+	if !ok {
+		log.Fatalf("❌ Unknown profile %q, expected one of %s.", name, strings.Join(slices.Sorted(maps.Keys(all)), ", "))
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(profile)) {
+		if key == "profile" {
+			log.Fatalf("❌ Profile %q cannot set another profile.", name)
+		}
+		if flag.Lookup(key) == nil {
+			log.Fatalf("❌ Profile %q sets unknown flag --%s.", name, key)
+		}
+		if cmdlineFlags[key] {
+			debugf("profile %s: --%s given explicitly", name, key)
+			continue
+		}
+		if err := flag.Set(key, profile[key]); err != nil {
+			log.Fatalf("❌ Profile %q sets invalid --%s %q: %v", name, key, profile[key], err)
+		}
+		debugf("profile %s: --%s=%s", name, key, profile[key])
+	}
+}
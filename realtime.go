@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// websocketModule is the WebSocket library of --realtime ws, the maintained
+// successor of nhooyr.io/websocket
+const websocketModule = "github.com/coder/websocket@v1.8.15"
+
+// realtimeRoute returns the method pattern and path of the --realtime
+// endpoint, and the api handler serving it
+func realtimeRoute() (string, string, string) {
+	if opts.Realtime == "ws" {
+		return "", "/ws", "api.EchoHandler"
+	}
+	return "GET ", "/events", "api.EventsHandler"
+}
+
+// sseSource is api/events.go, generated with --realtime sse
+const sseSource = `package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// EventsHandler streams server-sent events, a tick every second, until the
+// client disconnects. The connection outlives any request timeout.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for i := 1; ; i++ {
+		fmt.Fprintf(w, "event: tick\ndata: %d\n\n", i)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+`
+
+// wsSource is api/echo.go, generated with --realtime ws
+const wsSource = `package api
+
+import (
+	"net/http"
+
+	"github.com/coder/websocket"
+)
+
+// EchoHandler upgrades the request to a WebSocket and sends every message
+// back until the client closes the connection
+func EchoHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		// Accept has answered the failed handshake
+		return
+	}
+	defer conn.CloseNow()
+
+	for {
+		typ, data, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+		if err := conn.Write(r.Context(), typ, data); err != nil {
+			return
+		}
+	}
+}
+`
+
+// createRealtime writes the streaming handler of --realtime and documents
+// it in the service README
+func createRealtime(project, service string, port int) {
+	method, path, _ := realtimeRoute()
+	usage := fmt.Sprintf(`api/events.go streams server-sent events from the standard library alone:
+
+    curl -N localhost:%d%s/events`, port, routePrefix())
+	if opts.Realtime == "ws" {
+		writeFile(servicePackage(project, service, "api"), "echo.go", wsSource)
+		requireWebsocket(project, service)
+		usage = fmt.Sprintf(`api/echo.go echoes WebSocket messages with github.com/coder/websocket, the
+maintained successor of nhooyr.io/websocket:
+
+    websocat ws://localhost:%d%s/ws`, port, routePrefix())
+	} else {
+		writeFile(servicePackage(project, service, "api"), "events.go", sseSource)
+	}
+
+	// The stream skips the middlewares that would cut long-lived connections
+	var bypassed []string
+	if opts.TimeoutMiddleware {
+		bypassed = append(bypassed, "timeout")
+	}
+	if opts.RateLimit {
+		bypassed = append(bypassed, "rate limit")
+	}
+	if len(bypassed) > 0 {
+		noun := "middleware, which"
+		if len(bypassed) > 1 {
+			noun = "middlewares, which"
+		}
+		usage += fmt.Sprintf("\n\n%s bypasses the %s %s would cut\nlong-lived connections.",
+			"`"+method+routePrefix()+path+"`", strings.Join(bypassed, " and "), noun)
+	}
+
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## Realtime") {
+		appendContent(readme, fmt.Sprintf(`
+## Realtime
+
+%s
+`, usage))
+	}
+}
+
+// requireWebsocket adds the WebSocket library to the service module
+func requireWebsocket(project, service string) {
+	dir := moduleDir(project, service)
+	if opts.SkipTidy {
+		fmt.Println("⏭️  Skipped go get. Run it before building:")
+		fmt.Printf("   (cd %s && go get %s)\n", dir, websocketModule)
+		return
+	}
+	if err := runGoOutsideWorkspace(os.Stdout, dir, "get", websocketModule); err != nil {
+		warnf("Failed to go get %s in %s: %v", websocketModule, dir, err)
+	}
+}
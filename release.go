@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// createReleaseTooling writes CHANGELOG.md and the make release target
+// generated with --release-tooling
+func createReleaseTooling(project, service string) {
+	if _, err := os.Stat(filepath.Join(project, "CHANGELOG.md")); os.IsNotExist(err) {
+		writeFile(project, "CHANGELOG.md", fmt.Sprintf(`# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+
+## [Unreleased]
+
+### Added
+
+- Initial scaffold with the %s service
+`, service))
+	}
+
+	makefilePath := filepath.Join(project, "Makefile")
+	if fileContainsText(makefilePath, "release:") {
+		return
+	}
+
+	loop := `	@for dir in services/*/; do \
+		svc=$$(basename $$dir); \
+		go build -ldflags "$(LDFLAGS)" -o dist/$(VERSION)/$$svc-cli ./$${dir}cmd/cli || exit 1; \
+		go build -ldflags "$(LDFLAGS)" -o dist/$(VERSION)/$$svc-api ./$${dir}cmd/api || exit 1; \
+	done`
+	if goWorkOff() {
+		loop = `	@for dir in services/*/; do \
+		svc=$$(basename $$dir); \
+		go build -C $$dir -ldflags "$(LDFLAGS)" -o ../../dist/$(VERSION)/$$svc-cli ./cmd/cli || exit 1; \
+		go build -C $$dir -ldflags "$(LDFLAGS)" -o ../../dist/$(VERSION)/$$svc-api ./cmd/api || exit 1; \
+	done`
+	}
+	if opts.SingleModule {
+		loop = `	@for dir in internal/*/; do \
+		svc=$$(basename $$dir); \
+		go build -ldflags "$(LDFLAGS)" -o dist/$(VERSION)/$$svc-cli ./cmd/$${svc}cli || exit 1; \
+		go build -ldflags "$(LDFLAGS)" -o dist/$(VERSION)/$$svc-api ./cmd/$${svc}api || exit 1; \
+	done`
+	}
+
+	appendContent(makefilePath, `release: ## Build version-stamped binaries, make release VERSION=x.y.z
+	@if [ "$(origin VERSION)" = "file" ]; then echo "usage: make release VERSION=x.y.z"; exit 1; fi
+`+loop+`
+	@echo "📦 Binaries for $(VERSION) in dist/$(VERSION)"
+
+`)
+}
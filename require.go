@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// requireModules runs go get for every --require module in the service's
+// module. go mod tidy drops modules nothing imports, so their root packages
+// are blank-imported from the service's core package.
+func requireModules(project, service string) {
+	dir := moduleDir(project, service)
+	if opts.SkipTidy {
+		fmt.Println("⏭️  Skipped go get. Run it before building:")
+		fmt.Printf("   (cd %s && go get %s)\n", dir, strings.Join(opts.Require, " "))
+		return
+	}
+
+	var imports []string
+	for _, module := range opts.Require {
+		if err := runGoOutsideWorkspace(os.Stdout, dir, "get", module); err != nil {
+			warnf("Failed to go get %s in %s: %v", module, dir, err)
+			continue
+		}
+		fmt.Println("📦 Added", module, "to", dir)
+
+		// Repositories split into several modules, and tools, may have no
+		// importable root package
+		path, _, _ := strings.Cut(module, "@")
+		var name bytes.Buffer
+		err := runGoOutsideWorkspace(&name, dir, "list", "-f", "{{.Name}}", path)
+		if err != nil || strings.TrimSpace(name.String()) == "main" {
+			warnf("%s has no root package to import; go mod tidy drops it until code imports one of its packages", path)
+			continue
+		}
+		imports = append(imports, path)
+	}
+	if len(imports) == 0 {
+		return
+	}
+
+	pkg := serviceCorePackage(service)
+	var b strings.Builder
+	fmt.Fprintf(&b, `package %s
+
+// Modules added with --require, imported for side effects only so that
+// go mod tidy keeps them. Drop an import once real code uses the module.
+import (
+`, filepath.Base(pkg))
+	for _, path := range dedup(imports) {
+		fmt.Fprintf(&b, "\t_ %q\n", path)
+	}
+	b.WriteString(")\n")
+	writeFile(servicePackage(project, service, pkg), "require.go", formatGo(b.String()))
+}
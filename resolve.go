@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// maxParallelModules bounds how many modules are tidied and built at once
+const maxParallelModules = 4
+
+// errUnresolved marks a module whose dependencies could not be downloaded or
+// resolved, as opposed to generated code that does not compile
+var errUnresolved = errors.New("dependencies did not resolve")
+
+// unresolvedOutput matches the go command output of a failed module download
+// or lookup
+var unresolvedOutput = regexp.MustCompile(`no required module provides package|missing go\.sum entry|cannot find module providing package|module lookup disabled|unrecognized import path|unknown revision|invalid version|reading https?://|dial tcp|no such host|i/o timeout|connection refused|TLS handshake timeout`)
+
+// resolveModules runs go mod tidy and the build check in every module with
+// bounded concurrency and returns the failures joined together. Output is
+// buffered per module so parallel runs stay readable.
+func resolveModules(modules []string) error {
+	if opts.SkipTidy {
+		for _, dir := range modules {
+			goModTidy(dir)
+		}
+		return nil
+	}
+
+	var (
+		wg      sync.WaitGroup
+		printMu sync.Mutex
+		sem     = make(chan struct{}, maxParallelModules)
+		errs    = make([]error, len(modules))
+	)
+	for i, dir := range modules {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var out bytes.Buffer
+			errs[i] = resolveModule(&out, dir)
+
+			printMu.Lock()
+			os.Stdout.Write(out.Bytes())
+			printMu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// resolveModule tidies one module and makes sure its dependencies resolved
+func resolveModule(out *bytes.Buffer, dir string) error {
+	if err := runCmdTo(out, out, dir, "go", "mod", "tidy"); err != nil {
+		warned.Store(true)
+		fmt.Fprintf(out, "⚠️ Failed to run 'go mod tidy' in %s: %v\n", dir, err)
+	} else {
+		fmt.Fprintln(out, "🧹 go mod tidy run inside", dir)
+	}
+
+	if err := verifyBuild(out, dir); err != nil {
+		if unresolvedOutput.Match(out.Bytes()) {
+			return fmt.Errorf("   %s: %w: %w", dir, errUnresolved, err)
+		}
+		return fmt.Errorf("   %s: %w", dir, err)
+	}
+	return nil
+}
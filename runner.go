@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// createJustfile writes the justfile generated with --runner just. Recipes
+// mirror the Makefile; build, test and tidy walk every service so only the
+// run recipes need appending as services are added.
+func createJustfile(project string) {
+	justfilePath := filepath.Join(project, "justfile")
+	if _, err := os.Stat(justfilePath); err == nil {
+		return
+	}
+
+	build := `    for dir in services/*/; do
+        svc=$(basename "$dir")
+        go build -ldflags "{{ldflags}}" -o "bin/$svc-cli" "./${dir}cmd/cli"
+        go build -ldflags "{{ldflags}}" -o "bin/$svc-api" "./${dir}cmd/api"
+    done`
+	test := `    for dir in shared services/*/; do (cd "$dir" && GOWORK=off go test ./...); done`
+	tidy := `    for dir in shared services/*/; do (cd "$dir" && go mod tidy); done`
+	if goWorkOff() {
+		build = `    for dir in services/*/; do
+        svc=$(basename "$dir")
+        go build -C "$dir" -ldflags "{{ldflags}}" -o "../../bin/$svc-cli" ./cmd/cli
+        go build -C "$dir" -ldflags "{{ldflags}}" -o "../../bin/$svc-api" ./cmd/api
+    done`
+	}
+	if opts.SingleModule {
+		build = `    for dir in internal/*/; do
+        svc=$(basename "$dir")
+        go build -ldflags "{{ldflags}}" -o "bin/$svc-cli" "./cmd/${svc}cli"
+        go build -ldflags "{{ldflags}}" -o "bin/$svc-api" "./cmd/${svc}api"
+    done`
+		test = `    go test ./...`
+		tidy = `    go mod tidy`
+	}
+
+	writeFile(project, "justfile", fmt.Sprintf(`version := `+"`git describe --tags --always --dirty 2>/dev/null || echo dev`"+`
+commit := `+"`git rev-parse --short HEAD 2>/dev/null || echo none`"+`
+build_time := `+"`date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ`"+`
+ldflags := "-X %[1]s/shared/version.Version=" + version + " -X %[1]s/shared/version.Commit=" + commit + " -X %[1]s/shared/version.BuildTime=" + build_time
+
+# Build the api and cli binaries of every service
+build:
+    #!/usr/bin/env sh
+    set -e
+%[2]s
+
+# Run the tests of every module
+test:
+    #!/usr/bin/env sh
+    set -e
+%[3]s
+
+# Run go mod tidy in every module
+tidy:
+    #!/usr/bin/env sh
+    set -e
+%[4]s
+
+`, project, build, test, tidy))
+}
+
+// addJustfileRecipes appends the run recipes of a service unless present
+func addJustfileRecipes(project, service string) {
+	justfilePath := filepath.Join(project, "justfile")
+	if _, err := os.Stat(justfilePath); err != nil {
+		return
+	}
+	if !fileContainsText(justfilePath, fmt.Sprintf("run-%s-api", service)) {
+		appendContent(justfilePath, fmt.Sprintf(`run-%s-api:
+    %s
+
+run-%s-cli *args:
+    %s {{args}}
+
+`, service, goRunCmd(service, "api"), service, goRunCmd(service, "cli")))
+	}
+	if hasWorker(project, service) && !fileContainsText(justfilePath, fmt.Sprintf("run-%s-worker", service)) {
+		appendContent(justfilePath, fmt.Sprintf(`run-%s-worker:
+    %s
+
+`, service, goRunCmd(service, "worker")))
+	}
+}
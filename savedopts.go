@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v2"
+)
+
+// optionsFile records, at the project root, the options the project was
+// generated with, so update and later runs render the same features
+const optionsFile = ".create-go-project.yaml"
+
+// runFlags only affect the run they are given to, or describe the services
+// it creates, and are not saved. The layout flags are detected from the
+// project instead.
+var runFlags = map[string]bool{
+	"service": true, "yes": true, "debug": true, "strict": true, "show-config": true,
+	"wizard": true, "seed": true, "skip-tidy": true, "rollback": true, "atomic": true,
+	"overwrite-policy": true, "validate-templates": true, "print-tree": true,
+	"check-module": true, "reset-ports": true, "depends-on": true, "require": true,
+	"go-env": true, "type": true, "toolchain": true, "profile": true, "layout-file": true,
+	"os": true, "ddd": true, "single-module": true, "go-work-off": true, "workspace-root": true,
+}
+
+// savedOptions is the content of optionsFile
+type savedOptions struct {
+	Generator string         `yaml:"generator"`
+	Options   map[string]any `yaml:"options"`
+}
+
+// savedFlags holds the flags set from optionsFile, for --show-config
+var savedFlags = map[string]bool{}
+
+// loadSavedOptions sets the options saved in the project, except those given
+// on the command line or by the profile. It reports whether the project has
+// an options file.
+func loadSavedOptions(project string) bool {
+	data, err := os.ReadFile(filepath.Join(project, optionsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to read %s: %v", filepath.Join(project, optionsFile), err)
+	}
+	var saved savedOptions
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		log.Fatalf("❌ Invalid %s: %v", filepath.Join(project, optionsFile), err)
+	}
+
+	var profile map[string]string
+	if opts.Profile != "" {
+		profile = profiles()[opts.Profile]
+	}
+	for _, name := range slices.Sorted(maps.Keys(saved.Options)) {
+		if _, inProfile := profile[name]; cmdlineFlags[name] || inProfile || runFlags[name] {
+			continue
+		}
+		if flag.Lookup(name) == nil {
+			// A flag removed since the project was generated
+			warnf("Ignored --%s saved in %s: this version has no such flag", name, optionsFile)
+			continue
+		}
+		value := fmt.Sprint(saved.Options[name])
+		if err := flag.Set(name, value); err != nil {
+			log.Fatalf("❌ %s sets invalid --%s %q: %v", optionsFile, name, value, err)
+		}
+		savedFlags[name] = true
+		debugf("%s: --%s=%s", optionsFile, name, value)
+	}
+	return true
+}
+
+// saveOptions writes optionsFile from the options of this run that differ
+// from their defaults
+func saveOptions(project string) {
+	saved := savedOptions{Generator: toolVersion(), Options: map[string]any{}}
+	flag.VisitAll(func(f *flag.Flag) {
+		if runFlags[f.Name] || f.Value.String() == f.DefValue {
+			return
+		}
+		var value any = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = getter.Get()
+		}
+		saved.Options[f.Name] = value
+	})
+	out, err := yaml.Marshal(saved)
+	if err != nil {
+		warnf("Failed to render %s: %v", optionsFile, err)
+		return
+	}
+	rewriteFile(project, optionsFile, `# Options this project was generated with, read back by update, sync and
+# runs adding services. Flags given on the command line take precedence.
+`+string(out))
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// latestReleaseURL is the GitHub API endpoint of the newest release
+const latestReleaseURL = "https://api.github.com/repos/mathisi-io/create-go-project/releases/latest"
+
+// Release assets are raw binaries named create-go-project_<goos>_<goarch>
+// (.exe on Windows), listed with their SHA-256 in checksums.txt as
+// "<hex>  <name>" lines, the sha256sum format.
+const checksumsAsset = "checksums.txt"
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// selfUpdate implements the self-update subcommand: it replaces the running
+// binary with the latest release when that is newer
+func selfUpdate(args []string) {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	checkOnly := fs.Bool("check-only", false, "Only report whether a newer release exists")
+	force := fs.Bool("force", false, "Install the latest release even when it is not newer or the current version is unknown")
+	fs.Parse(args)
+
+	release, err := latestRelease()
+	if err != nil {
+		log.Fatalf("❌ Failed to check the latest release: %v", err)
+	}
+
+	current := toolVersion()
+	if newer, known := newerVersion(release.TagName, current); !*force {
+		if !known {
+			log.Fatalf("❌ Cannot compare create-go-project %s with the latest release %s; run self-update --force to install it anyway.", current, release.TagName)
+		}
+		if !newer {
+			fmt.Printf("✅ create-go-project %s is up to date (latest %s)\n", current, release.TagName)
+			return
+		}
+	}
+	fmt.Printf("⬆️  create-go-project %s is available (current %s)\n", release.TagName, current)
+	if *checkOnly {
+		return
+	}
+
+	name := fmt.Sprintf("create-go-project_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	binaryURL, checksumsURL := "", ""
+	for _, asset := range release.Assets {
+		switch asset.Name {
+		case name:
+			binaryURL = asset.URL
+		case checksumsAsset:
+			checksumsURL = asset.URL
+		}
+	}
+	if binaryURL == "" || checksumsURL == "" {
+		log.Fatalf("❌ Release %s has no %s or %s asset", release.TagName, name, checksumsAsset)
+	}
+
+	want, err := releaseChecksum(checksumsURL, name)
+	if err != nil {
+		log.Fatalf("❌ Failed to read checksums: %v", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("❌ Failed to locate the running binary: %v", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		log.Fatalf("❌ Failed to locate the running binary: %v", err)
+	}
+
+	// Download next to the binary so the final rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".create-go-project-*")
+	if err != nil {
+		log.Fatalf("❌ Failed to create a temporary file: %v", err)
+	}
+
+	got, err := download(binaryURL, tmp)
+	tmp.Close()
+	if err == nil && got != want {
+		err = fmt.Errorf("checksum mismatch: got %s, want %s", got, want)
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Fatalf("❌ Failed to install %s: %v", name, err)
+	}
+
+	// Windows cannot overwrite a running executable but can rename it
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		os.Remove(tmp.Name())
+		log.Fatalf("❌ Failed to replace %s: %v", exe, err)
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		os.Rename(old, exe)
+		os.Remove(tmp.Name())
+		log.Fatalf("❌ Failed to replace %s: %v", exe, err)
+	}
+	os.Remove(old)
+
+	fmt.Printf("✅ Updated %s to %s\n", exe, release.TagName)
+}
+
+func latestRelease() (githubRelease, error) {
+	var release githubRelease
+	req, err := http.NewRequest(http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return release, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return release, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return release, fmt.Errorf("GitHub API answered %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return release, err
+	}
+	if release.TagName == "" {
+		return release, errors.New("release has no tag")
+	}
+	return release, nil
+}
+
+// releaseChecksum returns the SHA-256 listed for name in the checksums file
+func releaseChecksum(url, name string) (string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// download writes url to w and returns the SHA-256 of the content
+func download(url string, w io.Writer) (string, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// newerVersion reports whether release is a later vMAJOR.MINOR.PATCH than
+// current, and whether both could be compared. With equal MAJOR.MINOR.PATCH
+// a prerelease or pseudo-version is older than the release itself.
+func newerVersion(release, current string) (newer, known bool) {
+	r, ok := parseVersion(release)
+	if !ok {
+		return false, false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false, false
+	}
+	for i := range r {
+		if r[i] != c[i] {
+			return r[i] > c[i], true
+		}
+	}
+	return isPrerelease(current) && !isPrerelease(release), true
+}
+
+// isPrerelease reports whether v has a -suffix, as prereleases and
+// pseudo-versions do; +build metadata does not count
+func isPrerelease(v string) bool {
+	core, _, _ := strings.Cut(v, "+")
+	return strings.Contains(core, "-")
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	core, _, _ := strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	core, _, _ = strings.Cut(core, "+")
+	fields := strings.Split(core, ".")
+	if !strings.HasPrefix(v, "v") || len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
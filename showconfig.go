@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// cmdlineFlags holds the flags given on the command line, recorded before
+// a profile sets its own
+var cmdlineFlags = map[string]bool{}
+
+// recordCmdlineFlags fills cmdlineFlags after flag.Parse
+func recordCmdlineFlags() {
+	flag.Visit(func(f *flag.Flag) {
+		cmdlineFlags[f.Name] = true
+	})
+}
+
+// showConfig prints the options resolved from the defaults, the profile,
+// the command line and the existing project as YAML, each changed value
+// commented with where it came from, for --show-config
+func showConfig(project string) {
+	yamlValue := func(v any) string {
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSuffix(string(out), "\n")
+	}
+	var profile map[string]string
+	if opts.Profile != "" {
+		profile = profiles()[opts.Profile]
+	}
+
+	fmt.Printf("# Effective options of create-go-project %s\n", toolVersion())
+	fmt.Printf("project: %s\n", yamlValue(project))
+	fmt.Println("options:")
+	flag.VisitAll(func(f *flag.Flag) {
+		if f.Name == "show-config" {
+			return
+		}
+		var value any = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			value = getter.Get()
+		}
+
+		source := ""
+		switch _, inProfile := profile[f.Name]; {
+		case cmdlineFlags[f.Name]:
+			source = "command line"
+		case inProfile:
+			source = "profile " + opts.Profile
+		case savedFlags[f.Name]:
+			source = "saved in " + optionsFile
+		case f.Name == "seed" && !opts.Yes:
+			source = "random, pin it with --seed"
+		case f.Value.String() != f.DefValue:
+			source = "implied by other options or the existing project"
+		}
+		if source != "" {
+			source = " # " + source
+		}
+		fmt.Printf("  %s: %s%s\n", f.Name, yamlValue(value), source)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+// shutdownSource is shared/shutdown/shutdown.go: the lifecycle shared by the
+// API, CLI and worker entrypoints, so each main handles signals the same way
+var shutdownSource = formatGo(`// Package shutdown gives every entrypoint the same lifecycle: a context
+// cancelled on SIGINT or SIGTERM, and a Group of long-running tasks that
+// stop together and are waited for before the process exits.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Context returns a context cancelled on the first SIGINT or SIGTERM. Call
+// stop once it is done: a second signal then kills the process at once.
+func Context() (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// Group runs tasks until its context is cancelled or one of them fails,
+// which cancels the others
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// NewGroup returns a Group whose tasks stop when ctx is done
+func NewGroup(ctx context.Context) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go runs task in a goroutine with the group's context. A task should
+// return once the context is done; returning early stops the group.
+func (g *Group) Go(task func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		err := task(g.ctx)
+		g.once.Do(func() {
+			g.err = err
+			g.cancel()
+		})
+	}()
+}
+
+// Wait blocks until every task returned and reports the first one's error
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// HTTPServer returns a Group task serving srv on lis until the context is
+// done, then letting in-flight requests finish for up to timeout
+func HTTPServer(srv *http.Server, lis net.Listener, timeout time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		errc := make(chan error, 1)
+		go func() { errc <- srv.Serve(lis) }()
+
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+`)
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// stageServices adds services to a copy of the existing project in a
+// temporary directory, by running the tool again there like --atomic, and
+// shows the changes to the project files before anything in the project is
+// written or any go command runs on it. Confirming copies the changes back;
+// declining, or a failing run, leaves the project untouched.
+func stageServices(project string, services []string) {
+	if !filepath.IsLocal(project) {
+		log.Fatalf("❌ Adding services needs a project path inside the current directory, got %q.", project)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	tmp, err := os.MkdirTemp("", "create-go-project-")
+	if err != nil {
+		log.Fatalf("❌ Failed to create the temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	staged := filepath.Join(tmp, project)
+	if err := copyTree(project, staged); err != nil {
+		os.RemoveAll(tmp)
+		log.Fatalf("❌ Failed to stage %s: %v", project, err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	cmd := exec.Command(exe, atomicArgs(project, services)...)
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), atomicEnv+"="+cwd)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	debugf("stage: adding %v to %s", services, staged)
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(tmp)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			fmt.Fprintf(os.Stderr, "🗑️  Discarded the staged changes; %s was not modified\n", project)
+			os.Exit(exitErr.ExitCode())
+		}
+		log.Fatalf("❌ Failed to run the generation: %v", err)
+	}
+
+	if !reviewChanges(snapshotFiles(project), snapshotFiles(staged)) {
+		os.RemoveAll(tmp)
+		log.Fatal("❌ Cancelled; the project was not modified.")
+	}
+	if err := applyTree(staged, project); err != nil {
+		os.RemoveAll(tmp)
+		log.Fatalf("❌ Failed to apply the staged changes to %s: %v", project, err)
+	}
+	fmt.Println("📦 Applied the changes to", project)
+
+	// The go.work of --workspace-root lies outside the staged copy
+	if opts.WorkspaceRoot != "" {
+		var modules []string
+		for _, service := range services {
+			for _, svc := range append([]string{service}, dependencies(service)...) {
+				workUse(project, svc)
+			}
+			modules = append(modules, moduleDir(project, service))
+		}
+		vendorModules(project, dedup(modules))
+	}
+}
+
+// deferWorkspace reports whether this run leaves the go.work of
+// --workspace-root to its parent run, as it works on a staged copy of the
+// project without it
+func deferWorkspace() bool {
+	return atomicChild() && opts.WorkspaceRoot != ""
+}
+
+// copyTree copies the directory src to dst, leaving out git data
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return copyEntry(path, filepath.Join(dst, rel), d)
+	})
+}
+
+// applyTree makes dst match src, outside git data: changed and new files
+// are copied and files src no longer has are removed
+func applyTree(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.Type().IsRegular() {
+			old, err := os.ReadFile(target)
+			new, err2 := os.ReadFile(path)
+			if err == nil && err2 == nil && bytes.Equal(old, new) {
+				return nil
+			}
+		}
+		return copyEntry(path, target, d)
+	})
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(dst, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(dst, path)
+		if _, err := os.Lstat(filepath.Join(src, rel)); errors.Is(err, fs.ErrNotExist) {
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+}
+
+// copyEntry copies the file, symlink or directory at path to target,
+// keeping its permissions
+func copyEntry(path, target string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	switch {
+	case d.IsDir():
+		return os.MkdirAll(target, info.Mode().Perm())
+	case d.Type()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		os.Remove(target)
+		return os.Symlink(link, target)
+	case d.Type().IsRegular():
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chmod(target, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// serviceTarget matches the Makefile and justfile targets generated per
+// service, capturing the service name
+var serviceTarget = regexp.MustCompile(`^(?:run-([\w.-]+)-(?:api|cli|worker|grpc|graphql)|seed-([\w.-]+)|loadtest-([\w.-]+)|docker-(?:build|push)-([\w.-]+))[\s:]`)
+
+// procfileEntry matches a Procfile line, capturing the service name
+var procfileEntry = regexp.MustCompile(`^([\w.-]+)-api:`)
+
+// syncProject reconciles the files listing every service with the services
+// found on disk: the entries of removed services are dropped, after
+// confirmation, and those of services added by hand are generated.
+func syncProject(project string) {
+	if _, err := os.Stat(filepath.Join(project, "shared", "go.mod")); err != nil && !opts.SingleModule {
+		log.Fatalf("❌ %s does not look like a generated project (no shared/go.mod or go.mod).", project)
+	}
+	services := listServices(project)
+	known := func(service string) bool { return slices.Contains(services, service) }
+
+	synced := map[string]string{}
+	for _, path := range []string{"Makefile", "justfile", "Procfile", "docker-compose.yml", "README.md"} {
+		data, err := os.ReadFile(filepath.Join(project, path))
+		if err != nil {
+			continue
+		}
+		current := string(data)
+		var content string
+		switch path {
+		case "Makefile", "justfile":
+			content = pruneBlocks(current, func(block string) bool {
+				m := serviceTarget.FindStringSubmatch(block)
+				return m != nil && !known(strings.Join(m[1:], ""))
+			})
+			if path == "Makefile" {
+				content = syncBuildTarget(project, content, services)
+			}
+		case "Procfile":
+			content = pruneLines(current, func(line string) bool {
+				m := procfileEntry.FindStringSubmatch(line)
+				return m != nil && !known(m[1])
+			})
+		case "docker-compose.yml":
+			// Only the blocks built from a service Dockerfile, not postgres
+			content = pruneBlocks(current, func(block string) bool {
+				name, _, _ := strings.Cut(strings.TrimSpace(block), ":")
+				dockerfile := fmt.Sprintf("dockerfile: %s/%s/Dockerfile", filepath.ToSlash(servicesRel()), name)
+				return strings.Contains(block, dockerfile) && !known(name)
+			})
+		case "README.md":
+			prefix := "- " + filepath.ToSlash(servicesRel()) + "/"
+			content = pruneLines(current, func(line string) bool {
+				name, _, ok := strings.Cut(strings.TrimPrefix(line, prefix), " (")
+				return strings.HasPrefix(line, prefix) && ok && !known(name)
+			})
+		}
+		if content != current {
+			synced[path] = content
+		}
+	}
+
+	if len(synced) > 0 {
+		fmt.Println("Entries of removed services and outdated build lines:")
+		for _, path := range slices.Sorted(maps.Keys(synced)) {
+			current, _ := os.ReadFile(filepath.Join(project, path))
+			fmt.Print(unifiedDiff(path, string(current), synced[path]))
+		}
+		if !confirm(fmt.Sprintf("This will rewrite %d files in %s.", len(synced), project)) {
+			log.Fatal("❌ Sync cancelled.")
+		}
+		for _, path := range slices.Sorted(maps.Keys(synced)) {
+			rewriteFile(project, path, synced[path])
+			fmt.Println("🔄 Synced:", path)
+		}
+	}
+	dropStaleWorkspaceModules(project)
+
+	// Then add what the services found on disk are missing
+	_, err := os.Stat(filepath.Join(project, "Procfile"))
+	hasProcfile := err == nil
+	_, err = os.Stat(filepath.Join(project, "docker-compose.yml"))
+	hasCompose := err == nil
+	for i, service := range services {
+		addMakefileTargets(project, service)
+		addJustfileRecipes(project, service)
+		if hasProcfile {
+			addProcfileEntry(project, service)
+		}
+		if hasCompose {
+			createCompose(project, service, servicePort(project, service, opts.BasePort+i))
+		}
+		addReadmeEntry(project, service)
+		if !opts.SingleModule && !goWorkOff() {
+			workUse(project, service)
+		}
+	}
+
+	fmt.Printf("\n✅ Project '%s' synced with its %d services\n", project, len(services))
+}
+
+// pruneBlocks drops the blank-line separated blocks of content for which
+// stale returns true
+func pruneBlocks(content string, stale func(block string) bool) string {
+	var kept []string
+	for _, block := range strings.SplitAfter(content, "\n\n") {
+		if !stale(block) {
+			kept = append(kept, block)
+		}
+	}
+	pruned := strings.Join(kept, "")
+	if strings.HasSuffix(pruned, "\n\n") && !strings.HasSuffix(content, "\n\n") {
+		pruned = strings.TrimSuffix(pruned, "\n")
+	}
+	return pruned
+}
+
+// pruneLines drops the lines of content for which stale returns true
+func pruneLines(content string, stale func(line string) bool) string {
+	var kept []string
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if !stale(strings.TrimSuffix(line, "\n")) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// syncBuildTarget rewrites the recipe of the Makefile build target, which
+// lists the entrypoints of every service
+func syncBuildTarget(project, content string, services []string) string {
+	header := "build: ## Build every service\n"
+	start := strings.Index(content, header)
+	if start < 0 {
+		return content
+	}
+	start += len(header)
+	end := start
+	for end < len(content) && content[end] == '\t' {
+		if next := strings.IndexByte(content[end:], '\n'); next >= 0 {
+			end += next + 1
+		} else {
+			end = len(content)
+		}
+	}
+	recipe := buildLines(services, func(service string) bool { return hasWorker(project, service) })
+	return content[:start] + recipe + content[end:]
+}
+
+// buildLines renders the build target recipe: the cli and api of every
+// service, and its worker when worker(service) is true
+func buildLines(services []string, worker func(service string) bool) string {
+	var lines string
+	for _, svc := range services {
+		lines += "\t" + goBuildCmd(svc, "cli", "$(LDFLAGS)") + "\n"
+		lines += "\t" + goBuildCmd(svc, "api", "$(LDFLAGS)") + "\n"
+		if worker(svc) {
+			lines += "\t" + goBuildCmd(svc, "worker", "$(LDFLAGS)") + "\n"
+		}
+	}
+	return lines
+}
+
+// dropStaleWorkspaceModules removes the go.work uses of service modules
+// whose directory no longer exists
+func dropStaleWorkspaceModules(project string) {
+	if opts.SingleModule || goWorkOff() {
+		return
+	}
+	workspace := workspaceDir(project)
+	var out bytes.Buffer
+	if err := runCmdTo(&out, os.Stderr, workspace, "go", "work", "edit", "-json"); err != nil {
+		warnf("Failed to read the go.work of %s", workspace)
+		return
+	}
+	var work struct {
+		Use []struct{ DiskPath string }
+	}
+	if err := json.Unmarshal(out.Bytes(), &work); err != nil {
+		warnf("Failed to read the go.work of %s: %v", workspace, err)
+		return
+	}
+	services := absPath(filepath.Join(project, servicesRel()))
+	for _, use := range work.Use {
+		dir := absPath(filepath.Join(workspace, use.DiskPath))
+		if filepath.Dir(dir) != services {
+			continue
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			continue
+		}
+		if err := runCmd(workspace, "go", "work", "edit", "-dropuse", use.DiskPath); err != nil {
+			warnf("Failed to drop %s from go.work", use.DiskPath)
+			continue
+		}
+		fmt.Println("🔄 Dropped from go.work:", use.DiskPath)
+	}
+}
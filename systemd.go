@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// createSystemd writes deploy/<service>.service for running the API binary
+// on a VM, and appends installation steps to the service README
+func createSystemd(project, service string, port int) {
+	// A module path project such as acme.dev/p runs as the user p
+	name := filepath.Base(project)
+	unit := name + "-" + service
+	portVar := "PORT"
+	if opts.EnvPrefix != "" {
+		portVar = envVar("server.port")
+	}
+	writeFile(filepath.Join(project, "deploy"), service+".service", fmt.Sprintf(`[Unit]
+Description=%[1]s %[2]s API
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+# Runs as a dedicated unprivileged user, see the service README
+User=%[1]s
+Group=%[1]s
+WorkingDirectory=/opt/%[1]s
+ExecStart=/opt/%[1]s/bin/%[2]s-api
+Environment=%[4]s=%[3]d
+Restart=on-failure
+RestartSec=5
+NoNewPrivileges=true
+ProtectSystem=strict
+ProtectHome=true
+PrivateTmp=true
+
+[Install]
+WantedBy=multi-user.target
+`, name, service, port, portVar))
+
+	installConfig := fmt.Sprintf("    sudo install -D -m 0640 -g %[1]s %[2]s/config/%[3]s /opt/%[1]s/%[2]s/config/%[3]s\n", name, filepath.ToSlash(serviceRel(service)), configFile())
+	if configInCode() {
+		installConfig = ""
+	}
+
+	readme := filepath.Join(serviceDir(project, service), "README.md")
+	if !fileContainsText(readme, "## systemd") {
+		appendContent(readme, fmt.Sprintf(`
+## systemd
+
+deploy/%[2]s.service runs bin/%[2]s-api from /opt/%[1]s, the directory
+config is loaded from. %[5]s in the unit overrides server.port. Create the
+dedicated %[1]s user once, then install from the project root:
+
+    make build
+    sudo useradd --system --no-create-home --shell /usr/sbin/nologin %[1]s
+    sudo install -D bin/%[2]s-api /opt/%[1]s/bin/%[2]s-api
+%[6]s    sudo cp deploy/%[2]s.service /etc/systemd/system/%[3]s.service
+    sudo systemctl daemon-reload
+    sudo systemctl enable --now %[3]s
+`, name, service, unit, filepath.ToSlash(serviceRel(service)), portVar, installConfig))
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// testifyModule is the assertion library of --test-framework testify
+const testifyModule = "github.com/stretchr/testify@v1.11.1"
+
+// testify reports whether generated tests assert with testify instead of
+// the standard library
+func testify() bool {
+	return opts.TestFramework == "testify"
+}
+
+// testifyImports returns the testify packages generated tests import
+func testifyImports() []string {
+	if !testify() {
+		return nil
+	}
+	return []string{"github.com/stretchr/testify/assert", "github.com/stretchr/testify/require"}
+}
+
+// createTests writes the handler unit tests and benchmarks generated with
+// --tests, and the make test and bench targets walking every module
+func createTests(project, service string) {
+	// With --arch clean and --ddd the handlers are delivery/http methods on
+	// a Handler built around the in-memory repository
+	dir, pkg, hello, greet, setup := servicePackage(project, service, handlersRel()), handlersPkg(), "HelloHandler", "GreetHandler", ""
+	var mods []string
+	if layeredArch() {
+		dir, pkg, hello, greet = servicePackage(project, service, "delivery/http"), "httpdelivery", "newTestHandler().Hello", "newTestHandler().Greet"
+		mods = greeterImports(project, service)
+		setup = "\nfunc newTestHandler() *Handler {\n\treturn NewHandler(" + newGreeter(service) + ")\n}\n"
+	}
+
+	std := []string{"net/http", "net/http/httptest", "strings", "testing"}
+	helloChecks := `if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "gopher") {
+		t.Errorf("body = %q, want it to greet gopher", rec.Body.String())
+	}`
+	greetCheck := `if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}`
+	if testify() {
+		helloChecks = `require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "gopher")`
+		greetCheck = "assert.Equal(t, tt.want, rec.Code)"
+	}
+
+	writeFile(dir, "handlers_test.go", goSource(pkg,
+		std,
+		append(mods, testifyImports()...),
+		setup+`
+func TestHelloHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	`+hello+`(rec, httptest.NewRequest(http.MethodGet, "/hello?name=gopher", nil))
+
+	`+helloChecks+`
+}
+
+func TestGreetHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `+"`"+`{"name":"gopher"}`+"`"+`, http.StatusOK},
+		{"missing name", `+"`"+`{}`+"`"+`, http.StatusUnprocessableEntity},
+		{"malformed", `+"`"+`{`+"`"+`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			`+greet+`(rec, httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(tt.body)))
+
+			`+greetCheck+`
+		})
+	}
+}
+`))
+
+	writeFile(dir, "handlers_bench_test.go", goSource(pkg,
+		[]string{"net/http", "net/http/httptest", "testing"},
+		nil,
+		`func BenchmarkHelloHandler(b *testing.B) {
+	req := httptest.NewRequest(http.MethodGet, "/hello?name=gopher", nil)
+	b.ReportAllocs()
+	for b.Loop() {
+		`+hello+`(httptest.NewRecorder(), req)
+	}
+}
+`))
+
+	if !layeredArch() {
+		writeFile(servicePackage(project, service, "internal/service"), "service_test.go", serviceTestSource())
+	}
+
+	writeFile(cmdDir(project, service, "api"), "main_test.go", serverTestSource(project, service))
+	if opts.TimeoutMiddleware {
+		writeFile(cmdDir(project, service, "api"), "timeout_test.go", timeoutTestSource(project, service))
+	}
+
+	loop := func(args string) string {
+		if args != "" {
+			args += " "
+		}
+		if opts.SingleModule {
+			return "\tgo test " + args + "./...\n"
+		}
+		return fmt.Sprintf("\tfor dir in shared services/*/; do (cd $$dir && GOWORK=off go test %s./...) || exit 1; done\n", args)
+	}
+	makefilePath := filepath.Join(project, "Makefile")
+	if !fileContainsText(makefilePath, "\ntest:") {
+		appendContent(makefilePath, "test: ## Run the tests of every module\n"+loop("")+"\n")
+	}
+	if !fileContainsText(makefilePath, "\nbench:") {
+		appendContent(makefilePath, "bench: ## Run the benchmarks of every module\n"+loop("-run=^$$ -bench=.")+"\n")
+	}
+}
+
+// serviceTestSource renders internal/service/service_test.go, checking Greet
+// and that it gives up on a cancelled context
+func serviceTestSource() string {
+	checks := `if err != nil {
+		t.Fatalf("Greet: %v", err)
+	}
+	if !strings.Contains(greeting, "gopher") {
+		t.Errorf("greeting = %q, want it to greet gopher", greeting)
+	}`
+	cancelled := `if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}`
+	std := []string{"context", "errors", "strings", "testing"}
+	if testify() {
+		checks = `require.NoError(t, err)
+	assert.Contains(t, greeting, "gopher")`
+		cancelled = "assert.ErrorIs(t, err, context.Canceled)"
+		std = []string{"context", "testing"}
+	}
+	return goSource("service", std, testifyImports(), `func TestGreet(t *testing.T) {
+	greeting, err := Greet(t.Context(), "gopher")
+	`+checks+`
+}
+
+func TestGreetCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := Greet(ctx, "gopher")
+	`+cancelled+`
+}
+`)
+}
+
+// serverTestSource renders cmd/api/main_test.go, booting the API through
+// run on an ephemeral port with the service config and querying its routes
+func serverTestSource(project, service string) string {
+	root, err := filepath.Rel(cmdDir(project, service, "api"), project)
+	if err != nil {
+		root = "."
+	}
+	routes := "{\"" + routePrefix() + "/hello?name=smoke\", \"smoke\"},"
+	if opts.Health {
+		routes += "\n\t\t{\"/healthz\", \"ok\"},"
+	}
+	std := []string{"context", "io", "net", "net/http", "testing", "time"}
+	check := `if res.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+			}
+			if !strings.Contains(string(body), tt.want) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.want)
+			}`
+	if testify() {
+		check = `require.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Contains(t, string(body), tt.want)`
+	} else {
+		std = append(std, "strings")
+	}
+
+	return goSource("main",
+		std,
+		testifyImports(),
+		fmt.Sprintf(`// TestServer boots the API as main does, on an ephemeral port, and checks
+// it answers and shuts down cleanly
+func TestServer(t *testing.T) {
+	// The config paths are relative to the project root
+	t.Chdir(%q)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, lis) }()
+
+	base := "http://" + lis.Addr().String()
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		%s
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			res, err := http.Get(base + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			%s
+		})
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %%v", err)
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatal("the server did not shut down")
+	}
+}
+`, filepath.ToSlash(root), routes, check))
+}
+
+// timeoutTestSource renders cmd/api/timeout_test.go for
+// --timeout-middleware: the configured deadline loads, and a slow handler
+// behind the API middlewares gets a 503 and a cancelled context
+func timeoutTestSource(project, service string) string {
+	root, err := filepath.Rel(cmdDir(project, service, "api"), project)
+	if err != nil {
+		root = "."
+	}
+	load, loadMods := loadConfigCall(project, service)
+	mods := append([]string{project + "/shared/config", project + "/shared/middleware", serviceImport(project, service, "api")}, loadMods...)
+
+	configCheck := `if err != nil {
+		t.Fatal(err)
+	}
+	if config.Context.Timeout <= 0 {
+		t.Fatalf("context.timeout = %v, want a positive deadline", config.Context.Timeout)
+	}`
+	statusCheck := `if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", res.StatusCode, http.StatusServiceUnavailable)
+	}`
+	if testify() {
+		configCheck = `require.NoError(t, err)
+	require.Positive(t, config.Context.Timeout, "context.timeout")`
+		statusCheck = "assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)"
+	}
+
+	return goSource("main",
+		[]string{"net/http", "net/http/httptest", "testing", "time"},
+		append(mods, testifyImports()...),
+		fmt.Sprintf(`// TestRequestTimeout checks the configured request deadline, then serves a
+// deliberately slow handler through the middlewares of the API router: the
+// client must get a 503 and the handler's context must be cancelled
+func TestRequestTimeout(t *testing.T) {
+	// The config paths are relative to the project root
+	t.Chdir(%q)
+	config, err := %s
+	%s
+
+	// Far shorter than the configured deadline, to keep the test fast
+	const timeout = 50 * time.Millisecond
+	cancelled := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(20 * timeout):
+			w.Write([]byte("too late"))
+		}
+	})
+	srv := httptest.NewServer(api.Wrap(middleware.Chain(slow, middleware.Timeout(timeout))))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	%s
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the handler's context was not cancelled")
+	}
+}
+`, filepath.ToSlash(root), load, configCheck, statusCheck))
+}
+
+// requireTestify pins testifyModule in the service's module. The new
+// service is not in go.work yet, so the workspace is turned off.
+func requireTestify(project, service string) {
+	dir := moduleDir(project, service)
+	if opts.SkipTidy {
+		fmt.Println("⏭️  Skipped go get. Run it before testing:")
+		fmt.Printf("   (cd %s && go get %s)\n", dir, testifyModule)
+		return
+	}
+	if err := runGoOutsideWorkspace(os.Stdout, dir, "get", testifyModule); err != nil {
+		warnf("Failed to go get %s in %s: %v", testifyModule, dir, err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// printTree writes the project as a tree diagram like tree(1), leaving out
+// the .git directory
+func printTree(out io.Writer, root string) error {
+	fmt.Fprintln(out, filepath.Base(root))
+	return printTreeDir(out, root, "")
+}
+
+func printTreeDir(out io.Writer, dir, prefix string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var visible []os.DirEntry
+	for _, entry := range entries {
+		if entry.Name() != ".git" {
+			visible = append(visible, entry)
+		}
+	}
+
+	for i, entry := range visible {
+		connector, indent := "├── ", "│   "
+		if i == len(visible)-1 {
+			connector, indent = "└── ", "    "
+		}
+		fmt.Fprintln(out, prefix+connector+entry.Name())
+		if entry.IsDir() {
+			if err := printTreeDir(out, filepath.Join(dir, entry.Name()), prefix+indent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderPathTree draws slash-separated paths under root as printTree does,
+// for a project that does not exist yet
+func renderPathTree(root string, paths []string) string {
+	type node map[string]node
+	tree := node{}
+	for _, path := range paths {
+		current := tree
+		for _, name := range strings.Split(path, "/") {
+			if current[name] == nil {
+				current[name] = node{}
+			}
+			current = current[name]
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(filepath.Base(root) + "\n")
+	var walk func(n node, prefix string)
+	walk = func(n node, prefix string) {
+		names := make([]string, 0, len(n))
+		for name := range n {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			connector, indent := "├── ", "│   "
+			if i == len(names)-1 {
+				connector, indent = "└── ", "    "
+			}
+			b.WriteString(prefix + connector + name + "\n")
+			walk(n[name], prefix+indent)
+		}
+	}
+	walk(tree, "")
+	return b.String()
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// updateProject re-renders the scaffold-owned files of an existing project
+// from the current templates. Handlers, CLI and internal service code are
+// user-owned and never touched.
+func updateProject(project string) {
+	if _, err := os.Stat(filepath.Join(project, "shared", "go.mod")); err != nil && !opts.SingleModule {
+		log.Fatalf("❌ %s does not look like a generated project (no shared/go.mod or go.mod).", project)
+	}
+
+	owned := ownedFiles(project)
+	configProto := configProtoDir + "/config.proto"
+
+	checkDroppedConfigSections(project, owned["shared/config/config.go"])
+
+	paths := make([]string, 0, len(owned))
+	for path := range owned {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Local edits to owned files are lost, so list what changes first
+	var changed []string
+	for _, path := range paths {
+		current, err := os.ReadFile(filepath.Join(project, path))
+		if err == nil && string(current) == owned[path] {
+			fmt.Println("✔️  Up to date:", path)
+			continue
+		}
+		changed = append(changed, path)
+	}
+	if len(changed) > 0 {
+		fmt.Println("\nChanges to re-render:")
+		for _, path := range changed {
+			current, _ := os.ReadFile(filepath.Join(project, path))
+			fmt.Print(unifiedDiff(path, string(current), owned[path]))
+		}
+		if !confirm(fmt.Sprintf("This will re-render %d files in %s, losing local edits to them.", len(changed), project)) {
+			log.Fatal("❌ Update cancelled.")
+		}
+	}
+
+	for _, path := range changed {
+		rewriteFile(project, path, owned[path])
+		fmt.Println("🔄 Updated:", path)
+		if path == configProto {
+			if err := generateProto(project); err != nil {
+				warnf("Failed to regenerate the config protobuf code: %v", err)
+			}
+		}
+	}
+
+	// Pick up dependencies introduced by the re-rendered shared code
+	sharedModule := filepath.Join(project, "shared")
+	if opts.SingleModule {
+		sharedModule = project
+	}
+	goModTidy(sharedModule)
+
+	// Add any run targets and Procfile entries introduced since the project
+	// was generated
+	_, err := os.Stat(filepath.Join(project, "Procfile"))
+	hasProcfile := err == nil || opts.Procfile
+	for _, service := range listServices(project) {
+		addMakefileTargets(project, service)
+		addJustfileRecipes(project, service)
+		if hasProcfile {
+			addProcfileEntry(project, service)
+		}
+	}
+
+	// Flags given to update are kept for the next runs
+	saveOptions(project)
+
+	fmt.Printf("\n✅ Project '%s' updated\n", project)
+}
+
+// ownedFiles renders the scaffold-owned shared files of project, by path
+func ownedFiles(project string) map[string]string {
+	owned := map[string]string{
+		".gitignore":                      gitignoreContent(),
+		"shared/apierror/apierror.go":     apierrorSource,
+		"shared/appctx/appctx.go":         appctxSource,
+		"shared/config/config.go":         configSource(project),
+		"shared/middleware/middleware.go": middlewareSource(project),
+		"shared/version/version.go":       versionSource,
+		"shared/pagination/pagination.go": paginationSource,
+		"shared/shutdown/shutdown.go":     shutdownSource,
+	}
+	if opts.Messaging == "nats" {
+		owned["shared/messaging/messaging.go"] = messagingSource()
+	}
+	if opts.Cache == "redis" {
+		owned["shared/cache/cache.go"] = cacheSource()
+	}
+	if opts.OTelLogs {
+		owned["shared/logging/trace.go"] = traceHandlerSource
+	}
+	if opts.HTTPClient {
+		owned["shared/httpclient/httpclient.go"] = httpclientSource
+	}
+	if opts.FeatureFlags {
+		owned["shared/flags/flags.go"] = flagsSource
+	}
+	if opts.Validation {
+		owned["shared/validate/validate.go"] = validateSource
+	}
+	// The config message follows the sections of shared/config
+	if protobufConfig() {
+		owned[configProtoDir+"/config.proto"] = configProtoSource(project)
+	}
+	return owned
+}
+
+// configSectionFlags names the flags adding each optional Config section
+var configSectionFlags = map[string]string{
+	"Database":  "--example-crud or --seed-data",
+	"Context":   "--timeout-middleware",
+	"RateLimit": "--ratelimit",
+	"Messaging": "--messaging nats",
+	"Cache":     "--cache redis",
+	"Pprof":     "--pprof",
+	"Features":  "--feature-flags",
+	"Commands":  "--config-commands",
+}
+
+// checkDroppedConfigSections stops update before it re-renders
+// shared/config without a section the service code still uses, which
+// would no longer build
+func checkDroppedConfigSections(project, rendered string) {
+	current, err := os.ReadFile(filepath.Join(project, "shared/config/config.go"))
+	if err != nil {
+		return
+	}
+	kept := configFields(rendered)
+	var dropped []string
+	for field := range configFields(string(current)) {
+		if !kept[field] {
+			dropped = append(dropped, field)
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	sort.Strings(dropped)
+
+	var problems []string
+	filepath.WalkDir(filepath.Join(project, servicesRel()), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return nil
+		}
+		used := selectedFields(path)
+		for _, field := range dropped {
+			if used[field] {
+				problems = append(problems, fmt.Sprintf("Config.%s, used by %s, needs %s", field, path, configSectionFlags[field]))
+			}
+		}
+		return nil
+	})
+	if len(problems) > 0 {
+		log.Fatalf("❌ Update would drop config sections still in use:\n  %s", strings.Join(problems, "\n  "))
+	}
+}
+
+// sharedFlags are the options that extend the shared module rather than
+// only the services
+var sharedFlags = []string{
+	"auth", "cache", "config-commands", "example-crud", "feature-flags", "httpclient",
+	"messaging", "otel-logs", "pprof", "ratelimit", "seed-data", "timeout-middleware", "validation",
+}
+
+// checkSharedCurrent stops a run adding services to project before it
+// scaffolds anything, when shared lacks what the options need: the new
+// services would not build until update re-renders it
+func checkSharedCurrent(project string) {
+	var missing []string
+	for path, rendered := range ownedFiles(project) {
+		current, err := os.ReadFile(filepath.Join(project, path))
+		if err != nil {
+			if path != ".gitignore" {
+				missing = append(missing, path)
+			}
+			continue
+		}
+		var have, want map[string]bool
+		switch path {
+		case "shared/config/config.go":
+			have, want = configFields(string(current)), configFields(rendered)
+			path = "Config"
+		case "shared/middleware/middleware.go":
+			have, want = exportedFuncs(string(current)), exportedFuncs(rendered)
+			path = "middleware"
+		}
+		for name := range want {
+			if !have[name] {
+				missing = append(missing, path+"."+name)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	sort.Strings(missing)
+
+	command := "create-go-project " + project + " update"
+	for _, name := range sharedFlags {
+		if cmdlineFlags[name] {
+			command += " --" + name
+			if f := flag.Lookup(name); f.DefValue != "false" {
+				command += " " + f.Value.String()
+			}
+		}
+	}
+	log.Fatalf("❌ The shared module of %s lacks what these options need:\n  %s\n"+
+		"   Run `%s` first.", project, strings.Join(missing, "\n  "), command)
+}
+
+// exportedFuncs returns the names of the exported functions declared in src
+func exportedFuncs(src string) map[string]bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "src.go", src, 0)
+	if err != nil {
+		return nil
+	}
+	funcs := map[string]bool{}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.IsExported() {
+			funcs[fn.Name.Name] = true
+		}
+	}
+	return funcs
+}
+
+// selectedFields returns the names the Go file at path selects from values,
+// as in cfg.Database.Host, leaving out package members such as
+// context.Context and method calls such as r.Context()
+func selectedFields(path string) map[string]bool {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil
+	}
+	calls := map[ast.Expr]bool{}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			calls[n.Fun] = true
+		case *ast.SelectorExpr:
+			// Package names are left unresolved by the parser
+			if x, ok := n.X.(*ast.Ident); ok && x.Obj == nil {
+				return true
+			}
+			if !calls[n] {
+				used[n.Sel.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// configFields returns the field names of the Config struct in src
+func configFields(src string) map[string]bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", src, 0)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != "Config" {
+			return true
+		}
+		if st, ok := spec.Type.(*ast.StructType); ok {
+			for _, field := range st.Fields.List {
+				for _, name := range field.Names {
+					fields[name.Name] = true
+				}
+			}
+		}
+		return false
+	})
+	return fields
+}
+
+// listServices returns the names of the services found under services/, or
+// internal/ in the single-module layout
+func listServices(project string) []string {
+	entries, err := os.ReadDir(filepath.Join(project, servicesRel()))
+	if err != nil {
+		return nil
+	}
+	var services []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			services = append(services, entry.Name())
+		}
+	}
+	return services
+}
@@ -0,0 +1,106 @@
+package main
+
+// validateSource is shared/validate/validate.go, generated with
+// --validation: the request validation every handler shares
+var validateSource = formatGo(renderTemplate(`// Package validate checks request structs against their validate struct
+// tags (github.com/go-playground/validator) and reports every invalid field
+// by its JSON name, with a message clients can show:
+//
+//	type CreateUserRequest struct {
+//		Email string §json:"email" validate:"required,email"§
+//		Age   int    §json:"age" validate:"min=18"§
+//	}
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is an invalid field of a request
+type FieldError struct {
+	Field   string §json:"field"§
+	Message string §json:"message"§
+}
+
+// Errors lists the invalid fields of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	problems := make([]string, len(e))
+	for i, fe := range e {
+		problems[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(problems, "; ")
+}
+
+// validate is safe for concurrent use and caches the tags of each struct
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Name fields as clients send them
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Validate checks v, a struct or a pointer to one, against its validate
+// tags. It returns Errors listing every invalid field, or nil.
+func Validate(v any) error {
+	err := validate.Struct(v)
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+	errs := make(Errors, 0, len(invalid))
+	for _, fe := range invalid {
+		// Drop the struct name: CreateUserRequest.address.city is address.city
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		errs = append(errs, FieldError{Field: field, Message: message(fe)})
+	}
+	return errs
+}
+
+// message phrases the failed check of fe. Add a case for custom tags.
+func message(fe validator.FieldError) string {
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		unit = " items"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", fe.Param(), unit)
+	}
+	return fmt.Sprintf("failed the %s check", fe.Tag())
+}
+`, '§'))
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// modFlag returns the -mod flag of the generated go build and go run
+// commands: with --vendor they build from vendor/ only
+func modFlag() string {
+	if opts.Vendor {
+		return " -mod=vendor"
+	}
+	return ""
+}
+
+// vendorDirs returns the directories whose vendor/ --vendor fills, and the
+// go subcommand doing it: the workspace root with go.work, else every module
+func vendorDirs(project string, modules []string) ([]string, string) {
+	switch {
+	case opts.SingleModule:
+		return []string{project}, "mod"
+	case goWorkOff():
+		return append([]string{filepath.Join(project, "shared")}, modules...), "mod"
+	default:
+		return []string{workspaceDir(project)}, "work"
+	}
+}
+
+// vendorModules copies the dependencies of the tidied modules into vendor/
+// with --vendor, for builds that never reach the module proxy
+func vendorModules(project string, modules []string) {
+	if !opts.Vendor || deferWorkspace() {
+		return
+	}
+	dirs, sub := vendorDirs(project, modules)
+	for _, dir := range dirs {
+		if err := runCmd(dir, "go", sub, "vendor"); err != nil {
+			warnf("Failed to run 'go %s vendor' in %s: %v", sub, dir, err)
+			continue
+		}
+		fmt.Printf("📦 go %s vendor run inside %s\n", sub, dir)
+	}
+}
+
+// addVendorTarget appends the make vendor target refreshing vendor/ after a
+// dependency change, unless present
+func addVendorTarget(project string) {
+	makefilePath := filepath.Join(project, "Makefile")
+	if !opts.Vendor || fileContainsText(makefilePath, "\nvendor:") {
+		return
+	}
+	recipe := "\tgo work vendor\n"
+	switch {
+	case opts.SingleModule:
+		recipe = "\tgo mod vendor\n"
+	case goWorkOff():
+		recipe = "\tfor dir in shared services/*/; do (cd $$dir && go mod vendor) || exit 1; done\n"
+	}
+	appendContent(makefilePath, "vendor: ## Refresh vendor/ after changing dependencies\n"+recipe+"\n")
+}
+
+// vendorIgnored drops vendor/ from the .gitignore patterns with --vendor,
+// since the vendored dependencies are committed
+func vendorIgnored(content string) string {
+	if !opts.Vendor {
+		return content
+	}
+	return strings.Replace(content, "vendor/\n", "", 1)
+}
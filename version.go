@@ -0,0 +1,22 @@
+package main
+
+import "runtime/debug"
+
+// toolURL is where the tool is published, linked from generated READMEs
+const toolURL = "https://github.com/mathisi-io/create-go-project"
+
+// version is stamped at release time with
+// -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// toolVersion returns the stamped version, or the module version recorded
+// by go install when the binary was not stamped
+func toolVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
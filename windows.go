@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// createBuildScript writes build.ps1, a PowerShell stand-in for the
+// Makefile generated when targeting Windows. Like the justfile it walks the
+// services directory, so nothing needs appending as services are added.
+func createBuildScript(project string) {
+	if _, err := os.Stat(filepath.Join(project, "build.ps1")); err == nil {
+		return
+	}
+
+	build := `        foreach ($svc in (Get-ChildItem -Directory services).Name) {
+            foreach ($kind in (Get-ChildItem -Directory "services\$svc\cmd").Name) {
+                Invoke-Go build -ldflags $LdFlags -o "bin\$svc-$kind.exe" ".\services\$svc\cmd\$kind"
+            }
+        }`
+	modules := `    @('shared') + (Get-ChildItem -Directory services | ForEach-Object { "services\$($_.Name)" })`
+	run := `        Invoke-Go run ".\services\$Service\cmd\$Kind"`
+	if goWorkOff() {
+		// Without go.work the root is not a module: build inside the service
+		// module, then run from the root where the config paths resolve
+		build = `        foreach ($svc in (Get-ChildItem -Directory services).Name) {
+            foreach ($kind in (Get-ChildItem -Directory "services\$svc\cmd").Name) {
+                Invoke-Go build -C "services\$svc" -ldflags $LdFlags -o "..\..\bin\$svc-$kind.exe" ".\cmd\$kind"
+            }
+        }`
+		run = `        Invoke-Go build -C "services\$Service" -o "..\..\bin\$Service-$Kind.exe" ".\cmd\$Kind"
+        & ".\bin\$Service-$Kind.exe"`
+	}
+	if opts.SingleModule {
+		build = `        foreach ($dir in (Get-ChildItem -Directory cmd).Name) {
+            Invoke-Go build -ldflags $LdFlags -o "bin\$dir.exe" ".\cmd\$dir"
+        }`
+		modules = `    @('.')`
+		run = `        Invoke-Go run ".\cmd\$Service$Kind"`
+	}
+
+	writeFile(project, "build.ps1", fmt.Sprintf(`# Windows alternative to the Makefile:
+#   .\build.ps1 [build|test|tidy|run] [-Service <name>] [-Kind api|cli|worker|grpc]
+param(
+    [ValidateSet('build', 'test', 'tidy', 'run')]
+    [string]$Target = 'build',
+    [string]$Service,
+    [string]$Kind = 'api'
+)
+$ErrorActionPreference = 'Stop'
+
+$Version = git describe --tags --always --dirty 2>$null
+if (-not $Version) { $Version = 'dev' }
+$Commit = git rev-parse --short HEAD 2>$null
+if (-not $Commit) { $Commit = 'none' }
+$BuildTime = (Get-Date).ToUniversalTime().ToString('yyyy-MM-ddTHH:mm:ssZ')
+$LdFlags = "-X %[1]s/shared/version.Version=$Version -X %[1]s/shared/version.Commit=$Commit -X %[1]s/shared/version.BuildTime=$BuildTime"
+
+function Invoke-Go {
+    go @args
+    if ($LASTEXITCODE -ne 0) { exit $LASTEXITCODE }
+}
+
+function Get-Modules {
+%[3]s
+}
+
+switch ($Target) {
+    'build' {
+%[2]s
+    }
+    'test' {
+        $env:GOWORK = 'off'
+        foreach ($dir in Get-Modules) {
+            Push-Location $dir
+            try { Invoke-Go test ./... } finally { Pop-Location }
+        }
+        Remove-Item Env:GOWORK
+    }
+    'tidy' {
+        foreach ($dir in Get-Modules) {
+            Push-Location $dir
+            try { Invoke-Go mod tidy } finally { Pop-Location }
+        }
+    }
+    'run' {
+        if (-not $Service) { throw '-Service is required, e.g. .\build.ps1 run -Service user' }
+%[4]s
+    }
+}
+`, project, build, modules, run))
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// wizardOption is a choice of a wizard step, read from and written to opts
+type wizardOption struct {
+	label string
+	get   func() bool
+	set   func(bool)
+}
+
+// wizardStep asks for a name when text is set, otherwise for one of its
+// options, or several with multi
+type wizardStep struct {
+	title   string
+	text    *string
+	options []wizardOption
+	multi   bool
+	cursor  int
+	picked  []bool
+}
+
+// wizard is the --wizard model. Every answer is applied to opts as soon as
+// it changes, so the preview shows what the flags now describe.
+type wizard struct {
+	steps     []*wizardStep
+	current   int
+	project   *string
+	services  *string
+	cancelled bool
+	hint      string
+}
+
+// errWizardCancelled is returned when the wizard is left without finishing
+var errWizardCancelled = errors.New("cancelled")
+
+// runWizard asks for the project, services and options the flags would
+// set, starting from the values already given on the command line
+func runWizard(project, services *string) error {
+	if *services == "" {
+		*services = "example"
+	}
+	w := &wizard{project: project, services: services, steps: wizardSteps(project, services)}
+	for _, step := range w.steps {
+		step.picked = make([]bool, len(step.options))
+		for i, option := range step.options {
+			step.picked[i] = option.get()
+			if step.picked[i] && !step.multi && step.cursor == 0 {
+				step.cursor = i
+			}
+		}
+	}
+
+	result, err := tea.NewProgram(w).Run()
+	if err != nil {
+		return err
+	}
+	if result.(*wizard).cancelled {
+		return errWizardCancelled
+	}
+	return nil
+}
+
+func wizardSteps(project, services *string) []*wizardStep {
+	integration := func(label string, field *bool) wizardOption {
+		return wizardOption{label: label,
+			get: func() bool { return *field },
+			set: func(v bool) { *field = v }}
+	}
+	// setting is an integration selected by a string option value
+	setting := func(label string, field *string, on, off string) wizardOption {
+		return wizardOption{label: label,
+			get: func() bool { return *field == on },
+			set: func(v bool) {
+				if v {
+					*field = on
+				} else {
+					*field = off
+				}
+			}}
+	}
+
+	return []*wizardStep{
+		{title: "Project name, which is also the module path (e.g. github.com/acme/shop)", text: project},
+		{title: "Services, comma-separated", text: services},
+		{title: "Layout", options: []wizardOption{
+			{label: "A module per service, tied by go.work",
+				get: func() bool { return !opts.SingleModule && !opts.GoWorkOff },
+				set: func(bool) { opts.SingleModule, opts.GoWorkOff = false, false }},
+			{label: "A module per service, with replace directives only (--go-work-off)",
+				get: func() bool { return opts.GoWorkOff },
+				set: func(bool) { opts.SingleModule, opts.GoWorkOff = false, true }},
+			{label: "One module for the whole project (--single-module)",
+				get: func() bool { return opts.SingleModule },
+				set: func(bool) { opts.SingleModule, opts.GoWorkOff = true, false }},
+		}},
+		{title: "Transport", options: []wizardOption{
+			{label: "HTTP",
+				get: func() bool { return opts.Transport == "http" },
+				set: func(bool) { opts.Transport, opts.GRPCGateway = "http", false }},
+			{label: "gRPC",
+				get: func() bool { return opts.Transport == "grpc" && !opts.GRPCGateway },
+				set: func(bool) { opts.Transport, opts.GRPCGateway = "grpc", false }},
+			{label: "gRPC with a REST gateway (--grpc-gateway)",
+				get: func() bool { return opts.Transport == "grpc" && opts.GRPCGateway },
+				set: func(bool) { opts.Transport, opts.GRPCGateway = "grpc", true }},
+			{label: "GraphQL",
+				get: func() bool { return opts.Transport == "graphql" },
+				set: func(bool) { opts.Transport, opts.GRPCGateway = "graphql", false }},
+		}},
+		{title: "Service internals", options: []wizardOption{
+			{label: "Flat packages",
+				get: func() bool { return opts.Arch == "flat" },
+				set: func(bool) { opts.Arch = "flat" }},
+			{label: "Clean architecture layers (--arch clean)",
+				get: func() bool { return opts.Arch == "clean" },
+				set: func(bool) { opts.Arch = "clean" }},
+			{label: "A package per aggregate (--ddd)",
+				get: func() bool { return opts.Arch == "ddd" },
+				set: func(bool) { opts.Arch = "ddd" }},
+		}},
+		{title: "Database", options: []wizardOption{
+			{label: "Schema only",
+				get: func() bool { return !opts.ExampleCRUD && !opts.SeedData },
+				set: func(bool) { opts.ExampleCRUD, opts.SeedData = false, false }},
+			{label: "Example CRUD over PostgreSQL (--example-crud)",
+				get: func() bool { return opts.ExampleCRUD && !opts.SeedData },
+				set: func(bool) { opts.ExampleCRUD, opts.SeedData = true, false }},
+			{label: "Example CRUD with seed data (--example-crud --seed-data)",
+				get: func() bool { return opts.ExampleCRUD && opts.SeedData },
+				set: func(bool) { opts.ExampleCRUD, opts.SeedData = true, true }},
+		}},
+		{title: "Integrations (space to toggle)", multi: true, options: []wizardOption{
+			integration("Dockerfile per service (--docker)", &opts.Docker),
+			integration("docker-compose.yml with postgres (--compose)", &opts.Compose),
+			integration("Handler tests and benchmarks (--tests)", &opts.Tests),
+			integration("Structured logging with slog (--structured-logging)", &opts.StructuredLogging),
+			integration("/healthz and a CLI healthcheck (--health)", &opts.Health),
+			integration("Typed HTTP client (--client)", &opts.Client),
+			integration("Shared outbound HTTP client (--httpclient)", &opts.HTTPClient),
+			setting("Queue worker entrypoint for every service (--type worker)", &opts.Type, "worker", "api"),
+			setting("Redis cache (--cache redis)", &opts.Cache, "redis", ""),
+			setting("NATS messaging (--messaging nats)", &opts.Messaging, "nats", ""),
+			setting("GitLab CI pipeline (--ci gitlab)", &opts.CI, "gitlab", ""),
+		}},
+	}
+}
+
+func (w *wizard) Init() tea.Cmd {
+	return nil
+}
+
+func (w *wizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return w, nil
+	}
+	step := w.steps[w.current]
+	w.hint = ""
+
+	switch key.String() {
+	case "ctrl+c":
+		w.cancelled = true
+		return w, tea.Quit
+	case "esc":
+		if w.current > 0 {
+			w.current--
+		}
+		return w, nil
+	case "enter":
+		if step.text != nil && strings.TrimSpace(*step.text) == "" {
+			w.hint = "A value is required."
+			return w, nil
+		}
+		if w.current == len(w.steps)-1 {
+			return w, tea.Quit
+		}
+		w.current++
+		return w, nil
+	}
+
+	if step.text != nil {
+		switch key.Type {
+		case tea.KeyBackspace:
+			if runes := []rune(*step.text); len(runes) > 0 {
+				*step.text = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes:
+			*step.text += string(key.Runes)
+		}
+		return w, nil
+	}
+
+	switch key.String() {
+	case "up", "k":
+		step.cursor = (step.cursor + len(step.options) - 1) % len(step.options)
+	case "down", "j":
+		step.cursor = (step.cursor + 1) % len(step.options)
+	case " ", "x":
+		if step.multi {
+			step.picked[step.cursor] = !step.picked[step.cursor]
+			step.options[step.cursor].set(step.picked[step.cursor])
+		}
+	}
+	if !step.multi {
+		for i := range step.picked {
+			step.picked[i] = i == step.cursor
+		}
+		step.options[step.cursor].set(true)
+	}
+	return w, nil
+}
+
+func (w *wizard) View() string {
+	if w.cancelled {
+		return ""
+	}
+	step := w.steps[w.current]
+
+	var b strings.Builder
+	b.WriteString(colorBold + "create-go-project wizard" + colorReset + "\n\n")
+	for i, s := range w.steps[:w.current] {
+		b.WriteString(colorGreen + "✔ " + colorReset + s.title + ": " + w.answer(i) + "\n")
+	}
+	b.WriteString(colorCyan + "? " + colorReset + colorBold + step.title + colorReset + "\n")
+
+	if step.text != nil {
+		b.WriteString("  " + *step.text + "█\n")
+	}
+	for i, option := range step.options {
+		cursor := "  "
+		if i == step.cursor {
+			cursor = colorCyan + "> " + colorReset
+		}
+		mark := "( ) "
+		switch {
+		case step.multi && step.picked[i]:
+			mark = "[x] "
+		case step.multi:
+			mark = "[ ] "
+		case step.picked[i]:
+			mark = "(•) "
+		}
+		b.WriteString(cursor + mark + option.label + "\n")
+	}
+	if w.hint != "" {
+		b.WriteString(colorRed + w.hint + colorReset + "\n")
+	}
+	b.WriteString("\n↑/↓ move · space toggle · enter next · esc back · ctrl+c quit\n\n")
+
+	b.WriteString(colorBold + "Preview" + colorReset + "\n")
+	b.WriteString(renderPathTree(*w.project, previewPaths(*w.services)))
+	return b.String()
+}
+
+// answer summarizes the answer of step i for the completed steps
+func (w *wizard) answer(i int) string {
+	step := w.steps[i]
+	if step.text != nil {
+		return *step.text
+	}
+	var labels []string
+	for j, picked := range step.picked {
+		if picked {
+			labels = append(labels, step.options[j].label)
+		}
+	}
+	if len(labels) == 0 {
+		return "none"
+	}
+	return strings.Join(labels, ", ")
+}
+
+// previewPaths lists the main files and directories the current options
+// generate, relative to the project
+func previewPaths(services string) []string {
+	paths := []string{"Makefile", "README.md", ".gitignore", optionsFile}
+	if opts.SingleModule {
+		paths = append(paths, "go.mod")
+	} else {
+		paths = append(paths, "shared/go.mod")
+		if !opts.GoWorkOff {
+			paths = append(paths, "go.work")
+		}
+	}
+	for _, pkg := range []string{"apierror", "appctx", "config", "middleware", "pagination", "shutdown", "version"} {
+		paths = append(paths, "shared/"+pkg)
+	}
+	if opts.Cache == "redis" {
+		paths = append(paths, "shared/cache")
+	}
+	if opts.Messaging == "nats" {
+		paths = append(paths, "shared/messaging")
+	}
+	if opts.HTTPClient {
+		paths = append(paths, "shared/httpclient")
+	}
+	if opts.FeatureFlags {
+		paths = append(paths, "shared/flags")
+	}
+	if opts.Validation {
+		paths = append(paths, "shared/validate")
+	}
+	if usesProtoModule() {
+		paths = append(paths, "buf.yaml", "buf.gen.yaml", protoModuleDirName, protoGenDir)
+	}
+	if opts.Compose {
+		paths = append(paths, "docker-compose.yml")
+	}
+	if opts.Helm {
+		paths = append(paths, "deploy/charts")
+	}
+	if opts.CI == "gitlab" {
+		paths = append(paths, ".gitlab-ci.yml")
+	}
+
+	for _, service := range strings.Split(services, ",") {
+		service = strings.TrimSpace(service)
+		if service == "" {
+			continue
+		}
+		pkgs := []string{"api", "cli", "db"}
+		if !configInCode() {
+			pkgs = append(pkgs, "config")
+		}
+		if opts.Arch == "clean" {
+			pkgs = append(pkgs, "internal/entity", "internal/usecase", "internal/repository", "delivery/http")
+		} else if opts.Arch == "ddd" {
+			pkgs = append(pkgs, "internal/"+aggregate(service), "delivery/http")
+		} else {
+			pkgs = append(pkgs, "internal/service")
+		}
+		if opts.ExampleCRUD {
+			pkgs = append(pkgs, "internal/repository")
+		}
+		if serviceType(service) == "worker" {
+			pkgs = append(pkgs, "internal/worker")
+		}
+		if opts.Transport == "graphql" {
+			pkgs = append(pkgs, "graph")
+		}
+		for _, pkg := range pkgs {
+			paths = append(paths, filepath.ToSlash(servicePackage("", service, pkg)))
+		}
+
+		kinds := []string{"api", "cli"}
+		switch opts.Transport {
+		case "grpc", "graphql":
+			kinds = append(kinds, opts.Transport)
+		}
+		if serviceType(service) == "worker" {
+			kinds = append(kinds, "worker")
+		}
+		for _, kind := range kinds {
+			paths = append(paths, filepath.ToSlash(cmdDir("", service, kind)))
+		}
+		if !opts.SingleModule {
+			paths = append(paths, filepath.ToSlash(filepath.Join(serviceRel(service), "go.mod")))
+		}
+		if opts.Docker || opts.Compose || opts.Helm {
+			paths = append(paths, filepath.ToSlash(filepath.Join(serviceRel(service), "Dockerfile")))
+		}
+	}
+	return paths
+}
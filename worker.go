@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// serviceTypes parses --type: a bare api or worker applies to every service
+// of the run, service=type pairs to the services named. It returns the type
+// of the services left unnamed and those of the named ones.
+func serviceTypes() (all string, named map[string]string, err error) {
+	named = map[string]string{}
+	for _, part := range strings.Split(opts.Type, ",") {
+		part = strings.TrimSpace(part)
+		service, kind, paired := strings.Cut(part, "=")
+		if !paired {
+			kind = part
+		}
+		if kind != "api" && kind != "worker" {
+			return "", nil, fmt.Errorf("unknown service type %q, expected api or worker", kind)
+		}
+		switch {
+		case paired:
+			named[strings.TrimSpace(service)] = kind
+		case all != "":
+			return "", nil, fmt.Errorf("%q gives every service more than one type", opts.Type)
+		default:
+			all = kind
+		}
+	}
+	if all == "" {
+		all = "api"
+	}
+	return all, named, nil
+}
+
+// checkServiceTypes stops on a --type pair naming a service not created by
+// this run
+func checkServiceTypes(services []string) {
+	_, named, _ := serviceTypes()
+	for service := range named {
+		if !slices.Contains(services, service) {
+			log.Fatalf("❌ --type names %s, which is not one of the services created: %s.", service, strings.Join(services, ", "))
+		}
+	}
+}
+
+// serviceType returns the type --type gives service: api or worker
+func serviceType(service string) string {
+	all, named, _ := serviceTypes()
+	if kind, ok := named[service]; ok {
+		return kind
+	}
+	return all
+}
+
+// createWorker adds the cmd/worker entrypoint generated with --type worker:
+// a consume loop over a stub queue that stops cleanly on SIGINT/SIGTERM
+func createWorker(project, service string) {
+	writeFile(servicePackage(project, service, "internal/worker"), "worker.go", goSource("worker",
+		[]string{"context", "log", "strconv", "time"},
+		nil,
+		`// Message is a unit of work read from the queue
+type Message struct {
+	ID   string
+	Body []byte
+}
+
+// Consumer receives messages from a queue. Receive blocks until a message
+// arrives or ctx is done.
+type Consumer interface {
+	Receive(ctx context.Context) (Message, error)
+}
+
+// TickerConsumer is a stub Consumer yielding a message every Interval.
+// Replace it with a Kafka, NATS or Redis consumer.
+type TickerConsumer struct {
+	Interval time.Duration
+	count    int
+}
+
+func (c *TickerConsumer) Receive(ctx context.Context) (Message, error) {
+	select {
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-time.After(c.Interval):
+		c.count++
+		return Message{ID: strconv.Itoa(c.count), Body: []byte("tick")}, nil
+	}
+}
+
+// Run hands every message to handle until ctx is cancelled. Handler errors
+// are logged and do not stop the loop.
+func Run(ctx context.Context, consumer Consumer, handle func(context.Context, Message) error) error {
+	for {
+		msg, err := consumer.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if err := handle(ctx, msg); err != nil {
+			log.Printf("⚠️ Failed to handle message %s: %v", msg.ID, err)
+		}
+	}
+}
+`))
+
+	writeFile(cmdDir(project, service, "worker"), "main.go", goSource("main",
+		[]string{"context", "log", "time"},
+		[]string{project + "/shared/shutdown", serviceImport(project, service, "internal/worker")},
+		fmt.Sprintf(`func main() {
+	// Cancelled on SIGINT/SIGTERM so the message in flight can finish
+	ctx, stop := shutdown.Context()
+	defer stop()
+
+	log.Println("👷 %[1]s worker started")
+	tasks := shutdown.NewGroup(ctx)
+	tasks.Go(func(ctx context.Context) error {
+		return worker.Run(ctx, &worker.TickerConsumer{Interval: time.Second}, func(ctx context.Context, msg worker.Message) error {
+			log.Printf("📨 Message %%s: %%s\n", msg.ID, msg.Body)
+			return nil
+		})
+	})
+	if err := tasks.Wait(); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("👋 %[1]s worker stopped")
+}
+`, service)))
+}
+
+// hasWorker reports whether the service has a cmd/worker entrypoint
+func hasWorker(project, service string) bool {
+	_, err := os.Stat(cmdDir(project, service, "worker"))
+	return err == nil
+}
+
+// entrypointsLabel lists the entrypoints of the service in the project
+// README: its worker is the one of this run, or already on disk for sync
+func entrypointsLabel(project, service string) string {
+	if serviceType(service) == "worker" || hasWorker(project, service) {
+		return "API, CLI, worker"
+	}
+	return "API, CLI"
+}